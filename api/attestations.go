@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	PredicateSPDX             = "https://spdx.dev/Document"
+	predicateSLSAPrefix       = "https://slsa.dev/provenance/"
+	predicateTypeAnnotation   = "in-toto.io/predicate-type"
+	referenceTypeAnnotation   = "vnd.docker.reference.type"
+	referenceDigestAnnotation = "vnd.docker.reference.digest"
+)
+
+//InTotoStatement is an in-toto attestation about one or more images.
+type InTotoStatement struct {
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate json.RawMessage `json:"predicate"`
+}
+
+//SBOMPackage is a package listed in a software bill of materials.
+type SBOMPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+//Material is a source or dependency that an image was built from.
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+//Provenance describes how an image was built, from SLSA v0.2 or v1 provenance.
+type Provenance struct {
+	BuilderId  string     `json:"builder_id"`
+	BuildType  string     `json:"build_type"`
+	StartedOn  *time.Time `json:"started_on,omitempty"`
+	FinishedOn *time.Time `json:"finished_on,omitempty"`
+	Materials  []Material `json:"materials"`
+}
+
+type spdxDocument struct {
+	Packages []struct {
+		Name             string `json:"name"`
+		VersionInfo      string `json:"versionInfo"`
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+		ExternalRefs     []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+type slsaProvenance struct {
+	//SLSA v0.2
+	Builder struct {
+		Id string `json:"id"`
+	} `json:"builder"`
+	BuildType string     `json:"buildType"`
+	Materials []Material `json:"materials"`
+	Metadata  *struct {
+		BuildStartedOn  *time.Time `json:"buildStartedOn"`
+		BuildFinishedOn *time.Time `json:"buildFinishedOn"`
+	} `json:"metadata"`
+	//SLSA v1
+	BuildDefinition *struct {
+		BuildType            string     `json:"buildType"`
+		ResolvedDependencies []Material `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails *struct {
+		Builder struct {
+			Id string `json:"id"`
+		} `json:"builder"`
+		Metadata *struct {
+			StartedOn  *time.Time `json:"startedOn"`
+			FinishedOn *time.Time `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+//GetAttestations gets the in-toto statements that buildkit stored in the index of an image, for the given platform.
+//Only statements with a predicate type that starts with the given prefix are returned.
+func (rc *RegistryClient) GetAttestations(repo, reference string, platform *Platform, predicatePrefix string) ([]*InTotoStatement, error) {
+	manifest, err := rc.GetManifest(repo, reference)
+	if err != nil {
+		return nil, err
+	}
+	index, err := manifest.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if !index.IsIndex() {
+		return nil, fmt.Errorf("%s is not a multi platform image, so it has no attestations", reference)
+	}
+	image, err := rc.ResolvePlatform(repo, manifest, platform)
+	if err != nil {
+		return nil, err
+	}
+	var output []*InTotoStatement
+	for _, m := range index.Manifests {
+		if m.Annotations[referenceTypeAnnotation] != "attestation-manifest" || m.Annotations[referenceDigestAnnotation] != image.Digest {
+			continue
+		}
+		attestation, err := rc.GetManifest(repo, m.Digest)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := attestation.Parse()
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range parsed.Layers {
+			if !strings.HasPrefix(layer.Annotations[predicateTypeAnnotation], predicatePrefix) {
+				continue
+			}
+			content, err := rc.GetBlob(repo, layer.Digest)
+			if err != nil {
+				return nil, err
+			}
+			var statement InTotoStatement
+			err = json.Unmarshal(content, &statement)
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, &statement)
+		}
+	}
+	return output, nil
+}
+
+//GetSBOM gets the packages from the SPDX attestations of an image, for the given platform.
+func (rc *RegistryClient) GetSBOM(repo, reference string, platform *Platform) ([]SBOMPackage, error) {
+	statements, err := rc.GetAttestations(repo, reference, platform, PredicateSPDX)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no SBOM found for %s", platform)
+	}
+	var output []SBOMPackage
+	for _, s := range statements {
+		packages, err := s.SBOMPackages()
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, packages...)
+	}
+	return output, nil
+}
+
+//GetProvenance gets the SLSA provenance of an image, for the given platform.
+func (rc *RegistryClient) GetProvenance(repo, reference string, platform *Platform) (*Provenance, error) {
+	statements, err := rc.GetAttestations(repo, reference, platform, predicateSLSAPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no provenance found for %s", platform)
+	}
+	return statements[0].Provenance()
+}
+
+//SBOMPackages gets the packages of an SPDX statement.
+func (s *InTotoStatement) SBOMPackages() ([]SBOMPackage, error) {
+	if s.PredicateType != PredicateSPDX {
+		return nil, fmt.Errorf("unsupported SBOM type %s", s.PredicateType)
+	}
+	var doc spdxDocument
+	err := json.Unmarshal(s.Predicate, &doc)
+	if err != nil {
+		return nil, err
+	}
+	var output []SBOMPackage
+	for _, p := range doc.Packages {
+		pkg := SBOMPackage{Name: p.Name, Version: p.VersionInfo, License: p.LicenseConcluded}
+		if pkg.License == "" || pkg.License == "NOASSERTION" {
+			pkg.License = p.LicenseDeclared
+		}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				pkg.PURL = ref.ReferenceLocator
+			}
+		}
+		output = append(output, pkg)
+	}
+	return output, nil
+}
+
+//Provenance gets the provenance of a SLSA v0.2 or v1 statement.
+func (s *InTotoStatement) Provenance() (*Provenance, error) {
+	if !strings.HasPrefix(s.PredicateType, predicateSLSAPrefix) {
+		return nil, fmt.Errorf("unsupported provenance type %s", s.PredicateType)
+	}
+	var p slsaProvenance
+	err := json.Unmarshal(s.Predicate, &p)
+	if err != nil {
+		return nil, err
+	}
+	output := &Provenance{BuilderId: p.Builder.Id, BuildType: p.BuildType, Materials: p.Materials}
+	if p.Metadata != nil {
+		output.StartedOn, output.FinishedOn = p.Metadata.BuildStartedOn, p.Metadata.BuildFinishedOn
+	}
+	if p.BuildDefinition != nil {
+		output.BuildType = p.BuildDefinition.BuildType
+		output.Materials = p.BuildDefinition.ResolvedDependencies
+	}
+	if p.RunDetails != nil {
+		output.BuilderId = p.RunDetails.Builder.Id
+		if p.RunDetails.Metadata != nil {
+			output.StartedOn, output.FinishedOn = p.RunDetails.Metadata.StartedOn, p.RunDetails.Metadata.FinishedOn
+		}
+	}
+	return output, nil
+}
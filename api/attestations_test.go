@@ -0,0 +1,107 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+//pushAttestedIndex stores an index with an amd64 image and a buildkit attestation manifest for it
+func pushAttestedIndex(registry *fakeRegistry, repo, tag string, statements map[string]string) string {
+	image := pushImage(registry, repo, "", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "layer")
+	attestation := api.Manifest{
+		SchemaVersion: 2,
+		MediaType:     api.MediaTypeOCIManifest,
+		Config:        &api.Descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: registry.addBlob([]byte("{}")), Size: 2},
+	}
+	for predicateType, predicate := range statements {
+		statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"%s","subject":[{"name":"%s","digest":{"sha256":"%s"}}],"predicate":%s}`,
+			predicateType, repo, strings.TrimPrefix(image, "sha256:"), predicate))
+		attestation.Layers = append(attestation.Layers, api.Descriptor{
+			MediaType:   "application/vnd.in-toto+json",
+			Digest:      registry.addBlob(statement),
+			Size:        int64(len(statement)),
+			Annotations: map[string]string{"in-toto.io/predicate-type": predicateType},
+		})
+	}
+	content, _ := json.Marshal(attestation)
+	attestationDigest := registry.addManifest(repo, "", api.MediaTypeOCIManifest, content)
+	index, _ := json.Marshal(api.Manifest{
+		SchemaVersion: 2,
+		MediaType:     api.MediaTypeOCIIndex,
+		Manifests: []api.Descriptor{
+			{MediaType: api.MediaTypeOCIManifest, Digest: image, Platform: &api.Platform{OS: "linux", Architecture: "amd64"}},
+			{
+				MediaType: api.MediaTypeOCIManifest,
+				Digest:    attestationDigest,
+				Platform:  &api.Platform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{
+					"vnd.docker.reference.type":   "attestation-manifest",
+					"vnd.docker.reference.digest": image,
+				},
+			},
+		},
+	})
+	return registry.addManifest(repo, tag, api.MediaTypeOCIIndex, index)
+}
+
+var _ = Describe("Attestations", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	amd64, _ := api.ParsePlatform("linux/amd64")
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+		pushAttestedIndex(registry, "user/repo", "1.0", map[string]string{
+			"https://spdx.dev/Document": `{"spdxVersion":"SPDX-2.3","packages":[
+				{"name":"musl","versionInfo":"1.2.4","licenseConcluded":"NOASSERTION","licenseDeclared":"MIT",
+				 "externalRefs":[{"referenceType":"purl","referenceLocator":"pkg:apk/alpine/musl@1.2.4"}]}]}`,
+			"https://slsa.dev/provenance/v0.2": `{"builder":{"id":"https://github.com/actions"},"buildType":"https://mobyproject.org/buildkit@v1",
+				"materials":[{"uri":"pkg:docker/alpine@3.19","digest":{"sha256":"abc"}}],
+				"metadata":{"buildStartedOn":"2024-01-01T10:00:00Z","buildFinishedOn":"2024-01-01T10:05:00Z"}}`,
+		})
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should read SBOM packages", func() {
+		packages, err := client.GetSBOM("user/repo", "1.0", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(packages).To(Equal([]api.SBOMPackage{{Name: "musl", Version: "1.2.4", License: "MIT", PURL: "pkg:apk/alpine/musl@1.2.4"}}))
+	})
+
+	It("should read provenance", func() {
+		provenance, err := client.GetProvenance("user/repo", "1.0", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provenance.BuilderId).To(Equal("https://github.com/actions"))
+		Expect(provenance.Materials).To(HaveLen(1))
+		Expect(provenance.Materials[0].URI).To(Equal("pkg:docker/alpine@3.19"))
+		Expect(provenance.FinishedOn.Sub(*provenance.StartedOn).Minutes()).To(Equal(5.0))
+	})
+
+	It("should read SLSA v1 provenance", func() {
+		statement := api.InTotoStatement{
+			PredicateType: "https://slsa.dev/provenance/v1",
+			Predicate: json.RawMessage(`{"buildDefinition":{"buildType":"https://actions.github.io/buildtypes/workflow/v1",
+				"resolvedDependencies":[{"uri":"git+https://github.com/user/repo@refs/heads/main"}]},
+				"runDetails":{"builder":{"id":"https://github.com/actions/runner"}}}`),
+		}
+		provenance, err := statement.Provenance()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provenance.BuilderId).To(Equal("https://github.com/actions/runner"))
+		Expect(provenance.Materials[0].URI).To(Equal("git+https://github.com/user/repo@refs/heads/main"))
+	})
+
+	It("should report missing attestations", func() {
+		arm64, _ := api.ParsePlatform("linux/arm64")
+		_, err := client.GetSBOM("user/repo", "1.0", arm64)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -20,6 +20,23 @@ type BuildSource struct {
 	Uuid        string `json:"uuid"`
 }
 
+//BuildSettings are the automated build settings of a repository
+type BuildSettings struct {
+	Provider string `json:"provider"`
+	//The git repository that's built, like owner/repo
+	BuildName string             `json:"build_name"`
+	SourceUrl string             `json:"source_url"`
+	BuildTags []BuildSettingsTag `json:"build_tags"`
+}
+
+//BuildSettingsTag is a tag that an automated build creates from a branch or git tag
+type BuildSettingsTag struct {
+	Name               string `json:"name"`
+	SourceType         string `json:"source_type"`
+	SourceName         string `json:"source_name"`
+	DockerfileLocation string `json:"dockerfile_location"`
+}
+
 func (b *BuildSource) GetSourceUrl() (*url.URL, error) {
 	rurl := url.URL{}
 	rurl.Scheme = "https"
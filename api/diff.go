@@ -0,0 +1,135 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+//ConfigChange is a difference in a single config field between two images.
+//Empty values mean that the field is not set in that image.
+type ConfigChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+//ImageDiff describes what changed between two images.
+type ImageDiff struct {
+	FromDigest    string       `json:"from_digest"`
+	ToDigest      string       `json:"to_digest"`
+	SharedLayers  []Descriptor `json:"shared_layers"`
+	RemovedLayers []Descriptor `json:"removed_layers"`
+	AddedLayers   []Descriptor `json:"added_layers"`
+	//Whether the shared layers are in a different order
+	LayersReordered bool           `json:"layers_reordered"`
+	FromSize        int64          `json:"from_size"`
+	ToSize          int64          `json:"to_size"`
+	SizeDelta       int64          `json:"size_delta"`
+	Config          []ConfigChange `json:"config"`
+}
+
+//IsEmpty checks if the images are the same.
+func (d *ImageDiff) IsEmpty() bool {
+	return d.FromDigest == d.ToDigest
+}
+
+//SameContent checks if the images have the same layers in the same order and no config differences.
+//Images with the same content can still differ in their manifests or in config fields that aren't compared, like history.
+func (d *ImageDiff) SameContent() bool {
+	return len(d.AddedLayers) == 0 && len(d.RemovedLayers) == 0 && !d.LayersReordered && len(d.Config) == 0
+}
+
+//DiffImages compares the layers and the config of two images.
+func DiffImages(from, to *Image) *ImageDiff {
+	diff := &ImageDiff{
+		FromDigest: from.Digest,
+		ToDigest:   to.Digest,
+		FromSize:   from.Size(),
+		ToSize:     to.Size(),
+	}
+	diff.SizeDelta = diff.ToSize - diff.FromSize
+	fromLayers := make(map[string]bool)
+	for _, l := range from.Manifest.Layers {
+		fromLayers[l.Digest] = true
+	}
+	toLayers := make(map[string]bool)
+	for _, l := range to.Manifest.Layers {
+		toLayers[l.Digest] = true
+		if fromLayers[l.Digest] {
+			diff.SharedLayers = append(diff.SharedLayers, l)
+		} else {
+			diff.AddedLayers = append(diff.AddedLayers, l)
+		}
+	}
+	shared := 0
+	for _, l := range from.Manifest.Layers {
+		if !toLayers[l.Digest] {
+			diff.RemovedLayers = append(diff.RemovedLayers, l)
+			continue
+		}
+		if shared < len(diff.SharedLayers) && diff.SharedLayers[shared].Digest != l.Digest {
+			diff.LayersReordered = true
+		}
+		shared++
+	}
+	diff.Config = diffConfigs(from.Config, to.Config)
+	return diff
+}
+
+func diffConfigs(from, to *ImageConfig) []ConfigChange {
+	var changes []ConfigChange
+	add := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, ConfigChange{Field: field, From: a, To: b})
+		}
+	}
+	add("architecture", from.Platform().String(), to.Platform().String())
+	add("os.version", from.OSVersion, to.OSVersion)
+	add("user", from.Config.User, to.Config.User)
+	add("workdir", from.Config.WorkingDir, to.Config.WorkingDir)
+	add("entrypoint", strings.Join(from.Config.Entrypoint, " "), strings.Join(to.Config.Entrypoint, " "))
+	add("cmd", strings.Join(from.Config.Cmd, " "), strings.Join(to.Config.Cmd, " "))
+	diffMaps("env.", envMap(from.Config.Env), envMap(to.Config.Env), add)
+	diffMaps("label.", from.Config.Labels, to.Config.Labels, add)
+	diffMaps("port.", portMap(from.Config.ExposedPorts), portMap(to.Config.ExposedPorts), add)
+	return changes
+}
+
+func diffMaps(prefix string, from, to map[string]string, add func(field, a, b string)) {
+	keys := make(map[string]bool)
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		add(prefix+k, from[k], to[k])
+	}
+}
+
+func envMap(env []string) map[string]string {
+	output := make(map[string]string)
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			output[parts[0]] = parts[1]
+		} else {
+			output[parts[0]] = ""
+		}
+	}
+	return output
+}
+
+func portMap(ports map[string]struct{}) map[string]string {
+	output := make(map[string]string)
+	for p := range ports {
+		output[p] = "exposed"
+	}
+	return output
+}
@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+//pushImage stores a single platform image in the fake registry and returns its manifest digest
+func pushImage(registry *fakeRegistry, repo, tag string, config api.ImageConfig, layers ...string) string {
+	configContent, _ := json.Marshal(config)
+	manifest := api.Manifest{
+		SchemaVersion: 2,
+		MediaType:     api.MediaTypeOCIManifest,
+		Config: &api.Descriptor{
+			MediaType: "application/vnd.oci.image.config.v1+json",
+			Digest:    registry.addBlob(configContent),
+			Size:      int64(len(configContent)),
+		},
+	}
+	for _, l := range layers {
+		manifest.Layers = append(manifest.Layers, api.Descriptor{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:    registry.addBlob([]byte(l)),
+			Size:      int64(len(l)),
+		})
+	}
+	content, _ := json.Marshal(manifest)
+	return registry.addManifest(repo, tag, api.MediaTypeOCIManifest, content)
+}
+
+//pushIndex stores an index of the given platform images in the fake registry
+func pushIndex(registry *fakeRegistry, repo, tag string, images map[string]string) string {
+	index := api.Manifest{SchemaVersion: 2, MediaType: api.MediaTypeOCIIndex}
+	for platform, digest := range images {
+		p, _ := api.ParsePlatform(platform)
+		index.Manifests = append(index.Manifests, api.Descriptor{
+			MediaType: api.MediaTypeOCIManifest,
+			Digest:    digest,
+			Platform:  p,
+		})
+	}
+	content, _ := json.Marshal(index)
+	return registry.addManifest(repo, tag, api.MediaTypeOCIIndex, content)
+}
+
+var _ = Describe("Diff", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	amd64, _ := api.ParsePlatform("linux/amd64")
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should resolve images for a platform", func() {
+		arm := pushImage(registry, "user/repo", "", api.ImageConfig{Architecture: "arm64", OS: "linux"}, "arm")
+		amd := pushImage(registry, "user/repo", "", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "amd")
+		pushIndex(registry, "user/repo", "latest", map[string]string{"linux/arm64/v8": arm, "linux/amd64": amd})
+		image, err := client.GetImage("user/repo", "latest", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.Digest).To(Equal(amd))
+		Expect(image.Config.Architecture).To(Equal("amd64"))
+		riscv, _ := api.ParsePlatform("linux/riscv64")
+		_, err = client.GetImage("user/repo", "latest", riscv)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should compare layers and configs", func() {
+		fromConfig := api.ImageConfig{Architecture: "amd64", OS: "linux"}
+		fromConfig.Config.Env = []string{"PATH=/bin", "OLD=1"}
+		fromConfig.Config.Cmd = []string{"nginx"}
+		toConfig := fromConfig
+		toConfig.Config = api.ContainerConfig{
+			Env:          []string{"PATH=/usr/bin"},
+			Cmd:          []string{"nginx"},
+			Labels:       map[string]string{"version": "2"},
+			ExposedPorts: map[string]struct{}{"80/tcp": {}},
+		}
+		pushImage(registry, "user/repo", "a", fromConfig, "base", "app-1")
+		pushImage(registry, "user/repo", "b", toConfig, "base", "app-2", "extra")
+		from, err := client.GetImage("user/repo", "a", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		to, err := client.GetImage("user/repo", "b", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		diff := api.DiffImages(from, to)
+		Expect(diff.SharedLayers).To(HaveLen(1))
+		Expect(diff.AddedLayers).To(HaveLen(2))
+		Expect(diff.RemovedLayers).To(HaveLen(1))
+		Expect(diff.SizeDelta).To(Equal(int64(len("app-2extra") - len("app-1"))))
+		Expect(diff.LayersReordered).To(BeFalse())
+		Expect(diff.IsEmpty()).To(BeFalse())
+		var fields []string
+		for _, c := range diff.Config {
+			fields = append(fields, fmt.Sprintf("%s:%s>%s", c.Field, c.From, c.To))
+		}
+		Expect(fields).To(Equal([]string{"env.OLD:1>", "env.PATH:/bin>/usr/bin", "label.version:>2", "port.80/tcp:>exposed"}))
+	})
+
+	It("should not treat reordered layers as identical", func() {
+		config := api.ImageConfig{Architecture: "amd64", OS: "linux"}
+		pushImage(registry, "user/repo", "a", config, "one", "two")
+		pushImage(registry, "user/repo", "b", config, "two", "one")
+		from, err := client.GetImage("user/repo", "a", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		to, err := client.GetImage("user/repo", "b", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		diff := api.DiffImages(from, to)
+		Expect(diff.IsEmpty()).To(BeFalse())
+		Expect(diff.AddedLayers).To(BeEmpty())
+		Expect(diff.RemovedLayers).To(BeEmpty())
+		Expect(diff.LayersReordered).To(BeTrue())
+		Expect(diff.SameContent()).To(BeFalse())
+		Expect(api.DiffImages(from, from).IsEmpty()).To(BeTrue())
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -58,6 +59,18 @@ func (d *DockerApi) getApiRoute(p string) string {
 }
 
 func (d *DockerApi) SetRepositoryDescription(username, name string, descShort, descLong string) error {
+	var short, full *string
+	if descShort != "" {
+		short = &descShort
+	}
+	if descLong != "" {
+		full = &descLong
+	}
+	return d.UpdateRepositoryDescription(username, name, short, full)
+}
+
+//UpdateRepositoryDescription sets the descriptions of a repository that aren't nil, even if they're empty
+func (d *DockerApi) UpdateRepositoryDescription(username, name string, descShort, descLong *string) error {
 	if username == "" {
 		return fmt.Errorf("no user given")
 	}
@@ -68,23 +81,20 @@ func (d *DockerApi) SetRepositoryDescription(username, name string, descShort, d
 	name = strings.ToLower(name)
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s", username, name)) + "/"
 	data := map[string]string{}
-	if descLong != "" {
-		data["full_description"] = descLong
+	if descLong != nil {
+		data["full_description"] = *descLong
 	}
-	if descShort != "" {
-		data["description"] = descShort
+	if descShort != nil {
+		data["description"] = *descShort
 	}
 	r, err := requests.Patch(d.client, pth, data, d.token)
 	if err != nil {
-		if r != nil {
-			return fmt.Errorf(parseError(r))
-		} else {
-			return err
-		}
+		return hubError(err, r)
 	}
 	return nil
 }
 
+//SetRepositoryPrivacy makes a repository private or public
 func (d *DockerApi) SetRepositoryPrivacy(username, name string, isPrivate bool) error {
 	if username == "" {
 		return fmt.Errorf("no user given")
@@ -99,23 +109,28 @@ func (d *DockerApi) SetRepositoryPrivacy(username, name string, isPrivate bool)
 		"is_private": isPrivate,
 	}, d.token)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return hubError(err, r)
 	}
-	log.Print(string(r))
 	return nil
 }
 
-//GetBuildSettings gets the build settings for a repository
-func (d *DockerApi) GetBuildSettings(username string, name string) (string, error) {
+//GetBuildSettings gets the build settings for a repository, nil if it has no automated builds
+func (d *DockerApi) GetBuildSettings(username string, name string) (*BuildSettings, error) {
 	username = strings.ToLower(username)
 	settingsPath := d.getRoute(fmt.Sprintf("repositories/%s/%s/autobuild", username, name))
 	r, err := requests.Get(d.client, settingsPath, d.token)
 	if err != nil {
-		log.Error(err)
-		return "", err
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, hubError(err, r)
+	}
+	var settings BuildSettings
+	err = json.Unmarshal(r, &settings)
+	if err != nil {
+		return nil, err
 	}
-	return string(r), nil
+	return &settings, nil
 }
 
 //GetBuildDetails Gets the details for a given build of a repository.
@@ -142,30 +157,43 @@ func (d *DockerApi) GetBuildDetails(username, name, code string) error {
 }
 
 //GetMyRepositories gets the repositories of the currently logged in user.
-func (d *DockerApi) GetMyRepositories() ([]UserRepository, error) {
+func (d *DockerApi) GetMyRepositories() (RepositoryList, error) {
 	if d.username == "" {
 		return nil, fmt.Errorf("user not authenticated")
 	}
 	return d.GetRepositories(d.username)
 }
 
-//GetRepositories gets the repositories of an user
-func (d *DockerApi) GetRepositories(username string) ([]UserRepository, error) {
+//GetRepositories gets all the repositories of an user or organization, going through all the pages
+func (d *DockerApi) GetRepositories(username string) (RepositoryList, error) {
 	if username == "" {
 		return nil, fmt.Errorf("no user given")
 	}
 	username = strings.ToLower(username)
-	pth := d.getRoute(fmt.Sprintf("users/%s/repositories", username))
-	r, err := requests.Get(d.client, pth, d.token)
-	if err != nil {
-		return nil, err
-	}
-	var repositories []UserRepository
-	err = json.Unmarshal(r, &repositories)
-	if err != nil {
-		return nil, err
+	var repositories RepositoryList
+	for page := 1; ; page++ {
+		pth := d.getRoute(fmt.Sprintf("repositories/%s/?page_size=100&page=%v", username, page))
+		r, err := requests.Get(d.client, pth, d.token)
+		if err != nil {
+			return nil, hubError(err, r)
+		}
+		var search SearchResult
+		err = json.Unmarshal(r, &search)
+		if err != nil {
+			return nil, err
+		}
+		var results []UserRepository
+		if search.Results != nil {
+			err = json.Unmarshal(search.Results, &results)
+			if err != nil {
+				return nil, err
+			}
+		}
+		repositories = append(repositories, results...)
+		if search.Next == nil {
+			return repositories, nil
+		}
 	}
-	return repositories, nil
 }
 
 //GetRepositoriesStarred Gets the starred repositories for a user.
@@ -308,9 +336,29 @@ func (d *DockerApi) GetTagsFromRepo(repo *Repository, pageSize, page int) (TagLi
 
 //GetTags - gets all the available tags for a repository
 func (d *DockerApi) GetTags(username, name string, pageSize, page int) (TagList, error) {
+	tags, _, err := d.getTagsPage(username, name, pageSize, page)
+	return tags, err
+}
+
+//GetAllTags - gets all the tags of a repository, going through all the pages
+func (d *DockerApi) GetAllTags(username, name string) (TagList, error) {
+	var output TagList
+	for page := 1; ; page++ {
+		tags, hasNext, err := d.getTagsPage(username, name, 100, page)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, tags...)
+		if !hasNext {
+			return output, nil
+		}
+	}
+}
+
+func (d *DockerApi) getTagsPage(username, name string, pageSize, page int) (TagList, bool, error) {
 	if username != "" && name == "" {
 		name = username
-		username = "library "
+		username = "library"
 	}
 	if username == "" || username == "_" {
 		username = "library"
@@ -325,19 +373,19 @@ func (d *DockerApi) GetTags(username, name string, pageSize, page int) (TagList,
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/tags?page_size=%v&page=%v", username, name, pageSize, page))
 	r, err := requests.Get(d.client, pth, d.token)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	var searchRes SearchResult
 	err = json.Unmarshal(r, &searchRes)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	var tags []Tag
 	err = json.Unmarshal(searchRes.Results, &tags)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return tags, nil
+	return tags, searchRes.Next != nil, nil
 }
 
 //GetMyRepository gets details about a user owned repository
@@ -471,15 +519,13 @@ func (d *DockerApi) CreateBuildTag(username, name, tagname, dockerFileLocation,
 	}
 	r, err := requests.Post(d.client, pth, data, d.token)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return hubError(err, r)
 	}
-	log.Print(r)
 	return nil
 }
 
 //CreateAutomatedBuild - Creates an automated build.
-func (d *DockerApi) CreateAutomatedBuild(username, name string, details map[string]string) error {
+func (d *DockerApi) CreateAutomatedBuild(username, name string, details map[string]interface{}) error {
 	if username == "" {
 		return fmt.Errorf("no user given")
 	}
@@ -502,10 +548,8 @@ func (d *DockerApi) CreateAutomatedBuild(username, name string, details map[stri
 	}
 	r, err := requests.Post(d.client, pth, data, d.token)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return hubError(err, r)
 	}
-	log.Print(r)
 	return nil
 }
 
@@ -535,11 +579,7 @@ func (d *DockerApi) CreateRepository(username, name string, isPrivate bool, desc
 	}
 	r, err := requests.Post(d.client, pth, data, d.token)
 	if err != nil {
-		if r != nil {
-			return nil, fmt.Errorf(parseError(r))
-		} else {
-			return nil, err
-		}
+		return nil, hubError(err, r)
 	}
 	var repo Repository
 	err = json.Unmarshal(r, &repo)
@@ -629,14 +669,15 @@ func (d *DockerApi) DeleteRepository(username, name string) error {
 	}
 	username = strings.ToLower(username)
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s", username, name)) + "/"
-	_, err := requests.Delete(d.client, pth, d.token)
+	r, err := requests.Delete(d.client, pth, d.token)
 	if err != nil {
-		return err
+		return hubError(err, r)
 	}
 	return nil
 }
 
 //DeleteTag - Deletes a tag for the given username and repository.
+//The manifest the tag pointed to is kept, see DeleteImages.
 func (d *DockerApi) DeleteTag(username, name, tag string) error {
 	if username == "" {
 		return fmt.Errorf("no user given")
@@ -651,27 +692,74 @@ func (d *DockerApi) DeleteTag(username, name, tag string) error {
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/tags/%s", username, name, tag))
 	r, err := requests.Delete(d.client, pth, d.token)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return fmt.Errorf("could not delete tag %s: %v", tag, hubError(err, r))
 	}
-	log.Print(r)
 	return nil
 }
 
+//DeleteTags deletes tags concurrently, returning the error for each tag that could not be deleted.
+func (d *DockerApi) DeleteTags(username, name string, tags []string, concurrency int) map[string]error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	failed := make(map[string]error)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tag := range queue {
+				err := d.DeleteTag(username, name, tag)
+				if err != nil {
+					lock.Lock()
+					failed[tag] = err
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	for _, tag := range tags {
+		queue <- tag
+	}
+	close(queue)
+	wg.Wait()
+	return failed
+}
+
+//RegistrySettings are the settings of an user or organization, with the number of private repositories used and available.
+type RegistrySettings struct {
+	PrivateRepoLimit      int    `json:"private_repo_limit"`
+	NumPrivateRepos       int    `json:"num_private_repos"`
+	DefaultRepoVisibility string `json:"default_repo_visibility"`
+}
+
+//PrivateReposRemaining gets how many more repositories can be made private.
+func (s *RegistrySettings) PrivateReposRemaining() int {
+	if s.NumPrivateRepos >= s.PrivateRepoLimit {
+		return 0
+	}
+	return s.PrivateRepoLimit - s.NumPrivateRepos
+}
+
 //GetRegistrySettings gets the settings for the current logged in user containing information about the number of private repositories used/available.
-func (d *DockerApi) GetRegistrySettings(username string) error {
+func (d *DockerApi) GetRegistrySettings(username string) (*RegistrySettings, error) {
 	if username == "" {
-		return fmt.Errorf("no user given")
+		return nil, fmt.Errorf("no user given")
 	}
 	username = strings.ToLower(username)
 	pth := d.getRoute(fmt.Sprintf("users/%s/registry-settings", username))
 	r, err := requests.Get(d.client, pth, d.token)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return nil, hubError(err, r)
 	}
-	log.Print(r)
-	return nil
+	var settings RegistrySettings
+	err = json.Unmarshal(r, &settings)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
 }
 
 //TODO Creates a build tag for a given repository.
@@ -766,15 +854,55 @@ func (d *DockerApi) AddCollaborator(username, name, collaborator string) error {
 		return fmt.Errorf("no collaborator given")
 	}
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/collaborators", username, name))
-	_, err := requests.Post(d.client, pth, map[string]string{
+	r, err := requests.Post(d.client, pth, map[string]string{
 		"user": collaborator,
 	}, d.token)
 	if err != nil {
-		return err
+		return hubError(err, r)
 	}
 	return nil
 }
 
+//Collaborator is an user that can push to a repository
+type Collaborator struct {
+	User string `json:"user"`
+}
+
+//GetCollaborators gets the collaborators of a repository, going through all the pages
+func (d *DockerApi) GetCollaborators(username, name string) ([]Collaborator, error) {
+	if username == "" {
+		return nil, fmt.Errorf("no user given")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no image name given")
+	}
+	username = strings.ToLower(username)
+	var collaborators []Collaborator
+	for page := 1; ; page++ {
+		pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/collaborators?page_size=100&page=%v", username, name, page))
+		r, err := requests.Get(d.client, pth, d.token)
+		if err != nil {
+			return nil, hubError(err, r)
+		}
+		var search SearchResult
+		err = json.Unmarshal(r, &search)
+		if err != nil {
+			return nil, err
+		}
+		var results []Collaborator
+		if search.Results != nil {
+			err = json.Unmarshal(search.Results, &results)
+			if err != nil {
+				return nil, err
+			}
+		}
+		collaborators = append(collaborators, results...)
+		if search.Next == nil {
+			return collaborators, nil
+		}
+	}
+}
+
 func (d *DockerApi) GetUsername() string {
 	return d.username
 }
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var argRx = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:=(.*))?$`)
+var varRx = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)(?::([-+])([^}]*))?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+//DockerfileStage is a build stage, started by a FROM instruction.
+type DockerfileStage struct {
+	//The image as written in the Dockerfile
+	Original string
+	//The image with all the build arguments substituted
+	Image    string
+	Name     string
+	Platform string
+	//Set if the stage is based on a previous stage instead of an image
+	FromStage bool
+	//The line that contains the image
+	line int
+}
+
+//IsScratch checks if the stage has no base image.
+func (s *DockerfileStage) IsScratch() bool {
+	return strings.ToLower(s.Image) == "scratch"
+}
+
+//Dockerfile is a parsed Dockerfile that keeps its original lines so it can be rewritten.
+type Dockerfile struct {
+	lines []string
+	//The build arguments declared before the first FROM
+	Args   map[string]string
+	Stages []*DockerfileStage
+	//The lines that declare each build argument, used when a base image is set through an argument
+	argLines  map[string]int
+	buildArgs map[string]string
+}
+
+type BaseImagePin struct {
+	Stage     *DockerfileStage
+	Reference *ImageReference
+	//The digest the image currently resolves to
+	Digest string
+	//One of pinned, unpinned, stale or an error message
+	Status string
+	//Set when the Dockerfile was rewritten to use Digest
+	Updated bool
+}
+
+//IsPinned checks if the base image is pinned to its current digest.
+func (p *BaseImagePin) IsPinned() bool {
+	return p.Status == "pinned"
+}
+
+//ParseDockerfile parses the FROM and global ARG instructions of a Dockerfile.
+//The given build arguments override the defaults from the Dockerfile.
+func ParseDockerfile(content string, buildArgs map[string]string) (*Dockerfile, error) {
+	d := &Dockerfile{
+		lines:     strings.Split(content, "\n"),
+		Args:      make(map[string]string),
+		argLines:  make(map[string]int),
+		buildArgs: buildArgs,
+	}
+	stageNames := make(map[string]bool)
+	for start := 0; start < len(d.lines); start++ {
+		instruction := strings.TrimSpace(d.lines[start])
+		//Join continued lines into a single instruction
+		end := start
+		for strings.HasSuffix(instruction, "\\") && end+1 < len(d.lines) {
+			end++
+			instruction = strings.TrimSuffix(instruction, "\\") + " " + strings.TrimSpace(d.lines[end])
+		}
+		if instruction == "" || strings.HasPrefix(instruction, "#") {
+			start = end
+			continue
+		}
+		fields := strings.Fields(instruction)
+		keyword := strings.ToUpper(fields[0])
+		switch {
+		case keyword == "ARG" && len(d.Stages) == 0:
+			for _, arg := range fields[1:] {
+				m := argRx.FindStringSubmatch(arg)
+				if m == nil {
+					return nil, fmt.Errorf("line %d: invalid ARG %s", start+1, arg)
+				}
+				d.Args[m[1]] = strings.Trim(m[2], `"'`)
+				d.argLines[m[1]] = d.findLine(start, end, arg)
+			}
+		case keyword == "FROM":
+			stage, err := d.parseFrom(fields[1:], start, end)
+			if err != nil {
+				return nil, err
+			}
+			stage.FromStage = stageNames[strings.ToLower(stage.Image)]
+			if stage.Name != "" {
+				stageNames[strings.ToLower(stage.Name)] = true
+			}
+			d.Stages = append(d.Stages, stage)
+		}
+		start = end
+	}
+	if len(d.Stages) == 0 {
+		return nil, fmt.Errorf("no FROM instruction found")
+	}
+	return d, nil
+}
+
+func (d *Dockerfile) parseFrom(args []string, start, end int) (*DockerfileStage, error) {
+	stage := &DockerfileStage{}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if strings.HasPrefix(args[0], "--platform=") {
+			stage.Platform = d.expand(strings.TrimPrefix(args[0], "--platform="))
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("line %d: FROM without an image", start+1)
+	}
+	stage.Original = args[0]
+	stage.Image = d.expand(args[0])
+	stage.line = d.findLine(start, end, args[0])
+	if len(args) == 3 && strings.ToUpper(args[1]) == "AS" {
+		stage.Name = args[2]
+	} else if len(args) != 1 {
+		return nil, fmt.Errorf("line %d: invalid FROM instruction", start+1)
+	}
+	return stage, nil
+}
+
+//findLine finds the line of an instruction that contains the given word
+func (d *Dockerfile) findLine(start, end int, word string) int {
+	for i := start; i <= end; i++ {
+		if strings.Contains(d.lines[i], word) {
+			return i
+		}
+	}
+	return start
+}
+
+func (d *Dockerfile) arg(name string) (string, bool) {
+	if v, ok := d.buildArgs[name]; ok {
+		return v, true
+	}
+	v, ok := d.Args[name]
+	return v, ok && v != ""
+}
+
+//expand substitutes build arguments in a string, supporting $VAR, ${VAR}, ${VAR:-default} and ${VAR:+alternative}
+func (d *Dockerfile) expand(str string) string {
+	return varRx.ReplaceAllStringFunc(str, func(match string) string {
+		m := varRx.FindStringSubmatch(match)
+		name := m[1] + m[4]
+		value, isSet := d.arg(name)
+		switch m[2] {
+		case "-":
+			if !isSet {
+				return m[3]
+			}
+		case "+":
+			if isSet {
+				return m[3]
+			}
+			return ""
+		}
+		return value
+	})
+}
+
+//BaseImages gets the stages that are based on an image from a registry.
+func (d *Dockerfile) BaseImages() []*DockerfileStage {
+	var output []*DockerfileStage
+	for _, s := range d.Stages {
+		if s.FromStage || s.IsScratch() {
+			continue
+		}
+		output = append(output, s)
+	}
+	return output
+}
+
+//Pin resolves each base image to its current digest and rewrites the Dockerfile to reference it as `name:tag@digest`.
+//Images that are set through a build argument are pinned by rewriting the default value of the argument.
+//Images that only have a digest are kept as they are.
+func (d *Dockerfile) Pin(resolve func(ref *ImageReference) (string, error)) []*BaseImagePin {
+	var output []*BaseImagePin
+	for _, stage := range d.BaseImages() {
+		pin := &BaseImagePin{Stage: stage}
+		output = append(output, pin)
+		ref, err := ParseReference(stage.Image)
+		if err != nil {
+			pin.Status = err.Error()
+			continue
+		}
+		pin.Reference = ref
+		if ref.Tag == "" && ref.Digest != "" {
+			//Without a tag there's nothing to compare the digest to, it's pinned on purpose
+			pin.Digest, pin.Status = ref.Digest, "pinned"
+			continue
+		}
+		pin.Digest, err = resolve(ref.WithTag(ref.Tag))
+		if err != nil {
+			pin.Status = fmt.Sprintf("could not resolve %s: %v", ref.WithTag(ref.Tag), err)
+			continue
+		}
+		switch ref.Digest {
+		case pin.Digest:
+			pin.Status = "pinned"
+			continue
+		case "":
+			pin.Status = "unpinned"
+		default:
+			pin.Status = "stale"
+		}
+		pin.Updated = d.rewriteImage(stage, pin.Digest)
+	}
+	return output
+}
+
+func (d *Dockerfile) rewriteImage(stage *DockerfileStage, digest string) bool {
+	image := stage.Original
+	line := stage.line
+	argName := ""
+	if m := varRx.FindStringSubmatch(image); m != nil && m[0] == image && m[2] == "" {
+		//The image is a single build argument, so we pin its default value instead.
+		argName = m[1] + m[4]
+		argLine, ok := d.argLines[argName]
+		if _, overridden := d.buildArgs[argName]; overridden || !ok || d.Args[argName] == "" {
+			return false
+		}
+		image, line = d.Args[argName], argLine
+	} else if image != stage.Image {
+		//Images that are assembled from multiple arguments can't be rewritten safely.
+		return false
+	}
+	//The tag is kept next to the digest, so the pin can be checked against the tag again later
+	pinned := strings.SplitN(image, "@", 2)[0]
+	if ref, err := ParseReference(pinned); err == nil && ref.Tag == "" {
+		pinned += ":latest"
+	}
+	pinned += "@" + digest
+	d.lines[line] = strings.Replace(d.lines[line], image, pinned, 1)
+	if argName != "" {
+		//Other stages may use the same argument
+		d.Args[argName] = pinned
+	}
+	return true
+}
+
+func (d *Dockerfile) String() string {
+	return strings.Join(d.lines, "\n")
+}
@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Dockerfile", func() {
+	digest := "sha256:" + "cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34cd34"
+	oldDigest := "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+	resolve := func(ref *api.ImageReference) (string, error) {
+		if ref.Repository() == "library/missing" {
+			return "", fmt.Errorf("not found")
+		}
+		return digest, nil
+	}
+
+	It("should parse stages and build arguments", func() {
+		df, err := api.ParseDockerfile(`ARG GO=1.22
+ARG BASE
+FROM --platform=$BUILDPLATFORM golang:${GO}-alpine AS build
+RUN go build
+FROM build AS test
+FROM ${BASE:-alpine:3.19}
+COPY --from=build /app /app
+`, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(df.Stages).To(HaveLen(3))
+		Expect(df.Stages[0].Image).To(Equal("golang:1.22-alpine"))
+		Expect(df.Stages[0].Name).To(Equal("build"))
+		Expect(df.Stages[1].FromStage).To(BeTrue())
+		Expect(df.Stages[2].Image).To(Equal("alpine:3.19"))
+		Expect(df.BaseImages()).To(HaveLen(2))
+	})
+
+	It("should pin images and build arguments", func() {
+		df, err := api.ParseDockerfile(`ARG BASE=debian:12
+FROM \
+  nginx:1.25 AS web
+FROM $BASE
+FROM ubuntu:22.04@`+oldDigest+`
+FROM scratch
+`, nil)
+		Expect(err).NotTo(HaveOccurred())
+		pins := df.Pin(resolve)
+		Expect(pins).To(HaveLen(3))
+		Expect(pins[0].Status).To(Equal("unpinned"))
+		Expect(pins[2].Status).To(Equal("stale"))
+		for _, p := range pins {
+			Expect(p.Updated).To(BeTrue())
+		}
+		Expect(df.String()).To(Equal(`ARG BASE=debian:12@` + digest + `
+FROM \
+  nginx:1.25@` + digest + ` AS web
+FROM $BASE
+FROM ubuntu:22.04@` + digest + `
+FROM scratch
+`))
+		df, _ = api.ParseDockerfile(df.String(), nil)
+		for _, p := range df.Pin(resolve) {
+			Expect(p.IsPinned()).To(BeTrue())
+		}
+	})
+
+	It("should pin untagged images to latest so they can be checked again", func() {
+		df, err := api.ParseDockerfile("FROM node\n", nil)
+		Expect(err).NotTo(HaveOccurred())
+		df.Pin(resolve)
+		Expect(df.String()).To(Equal("FROM node:latest@" + digest + "\n"))
+		df, _ = api.ParseDockerfile(df.String(), nil)
+		pins := df.Pin(func(ref *api.ImageReference) (string, error) {
+			Expect(ref.Tag).To(Equal("latest"))
+			return oldDigest, nil
+		})
+		Expect(pins[0].Status).To(Equal("stale"))
+		Expect(df.String()).To(Equal("FROM node:latest@" + oldDigest + "\n"))
+	})
+
+	It("should keep images that only have a digest", func() {
+		dockerfile := "FROM alpine@" + oldDigest + "\n"
+		df, err := api.ParseDockerfile(dockerfile, nil)
+		Expect(err).NotTo(HaveOccurred())
+		pins := df.Pin(resolve)
+		Expect(pins).To(HaveLen(1))
+		Expect(pins[0].IsPinned()).To(BeTrue())
+		Expect(pins[0].Digest).To(Equal(oldDigest))
+		Expect(pins[0].Updated).To(BeFalse())
+		Expect(df.String()).To(Equal(dockerfile))
+	})
+
+	It("should not rewrite overridden or unresolved images", func() {
+		df, err := api.ParseDockerfile("ARG BASE=debian:12\nFROM $BASE\nFROM missing:1\n", map[string]string{"BASE": "alpine"})
+		Expect(err).NotTo(HaveOccurred())
+		pins := df.Pin(resolve)
+		Expect(pins[0].Stage.Image).To(Equal("alpine"))
+		Expect(pins[0].Updated).To(BeFalse())
+		Expect(pins[1].Digest).To(BeEmpty())
+		Expect(df.String()).To(Equal("ARG BASE=debian:12\nFROM $BASE\nFROM missing:1\n"))
+	})
+})
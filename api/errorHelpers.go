@@ -2,6 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -11,11 +14,64 @@ type dockerError struct {
 	Detail *string  `json:"detail"`
 }
 
+type registryErrors struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+//RegistryError is returned when the registry responds with an error status.
+type RegistryError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RegistryError) Error() string {
+	return fmt.Sprintf("registry error %d: %s", e.StatusCode, e.Message)
+}
+
+//HubError is returned when the hub api responds with an error status.
+type HubError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HubError) Error() string {
+	return fmt.Sprintf("hub error %d: %s", e.StatusCode, e.Message)
+}
+
+//IsNotFound checks if the error is a registry or hub error for content that does not exist.
+func IsNotFound(err error) bool {
+	switch e := err.(type) {
+	case *RegistryError:
+		return e.StatusCode == http.StatusNotFound
+	case *HubError:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return err != nil && err.Error() == strconv.Itoa(http.StatusNotFound)
+}
+
+//hubError turns the status error of a hub api request into a HubError, with the message of the response if it has one.
+//Other errors, like network errors, are returned as they are.
+func hubError(err error, body []byte) error {
+	status, convErr := strconv.Atoi(err.Error())
+	if convErr != nil {
+		return err
+	}
+	message := parseError(body)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HubError{StatusCode: status, Message: message}
+}
+
+//parseError gets the message of a hub api error response, empty if the response has none.
 func parseError(errb []byte) string {
 	var data dockerError
 	err := json.Unmarshal(errb, &data)
 	if err != nil {
-		return "could not parse error"
+		return ""
 	}
 	if data.Error != nil {
 		return *data.Error
@@ -26,5 +82,18 @@ func parseError(errb []byte) string {
 	if len(data.Name) > 0 {
 		return strings.Join(data.Name, "\n")
 	}
-	return "unresolved error"
+	return ""
+}
+
+func parseRegistryError(errb []byte) string {
+	var data registryErrors
+	err := json.Unmarshal(errb, &data)
+	if err != nil || len(data.Errors) == 0 {
+		return strings.TrimSpace(string(errb))
+	}
+	var messages []string
+	for _, e := range data.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.Code, e.Message))
+	}
+	return strings.Join(messages, "\n")
 }
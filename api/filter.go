@@ -0,0 +1,613 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//Filterable is anything that filters can be evaluated against, like tags and repositories.
+type Filterable interface {
+	//FilterValue gets the value of a field, which is a string, float64, bool, time.Time or []string.
+	FilterValue(field string) (interface{}, bool)
+}
+
+//Filter is a parsed filter expression, like `pulls > 1000 && updated < 30d && name =~ "^svc-"`.
+//Comparisons can be combined with &&, || and !, and grouped with parentheses.
+//Sizes can have units like 200MB. Times can be compared to ages like 30d, which compare how long ago the time was,
+//so `updated < 30d` means updated in the last 30 days. `updated within 30d` means the same.
+//Times can also be compared to dates like "2020-01-31", where < is earlier and == matches the whole day,
+//or to points in time counted back from now like -30d, so `updated > -30d` is another way to write `updated < 30d`.
+//Fields with multiple values, like the architectures of a tag, match if any of the values match.
+type Filter struct {
+	expression string
+	root       filterNode
+}
+
+type filterNode interface {
+	eval(target Filterable, now time.Time) (bool, error)
+	//validate checks every comparison against the fields of the target, without short circuits
+	validate(target Filterable, now time.Time) error
+}
+
+type filterToken struct {
+	kind  string
+	value string
+}
+
+const (
+	tokenIdent    = "ident"
+	tokenString   = "string"
+	tokenNumber   = "number"
+	tokenOperator = "operator"
+)
+
+var filterOperators = []string{"&&", "||", "==", "!=", ">=", "<=", "=~", "!~", ">", "<", "!", "(", ")"}
+
+//ParseFilter parses a filter expression.
+func ParseFilter(expression string) (*Filter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %v", expression, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid filter %s: unexpected %s", expression, p.tokens[p.pos].value)
+	}
+	return &Filter{expression: expression, root: root}, nil
+}
+
+func (f *Filter) String() string {
+	return f.expression
+}
+
+//Match checks if the target matches the filter.
+func (f *Filter) Match(target Filterable) (bool, error) {
+	return f.root.eval(target, time.Now())
+}
+
+//Validate checks that the fields of the filter exist on the target and that their comparisons are valid.
+//Every comparison is checked, even the ones that Match would skip, so typos are reported before anything is filtered.
+//The target is usually an empty value of the type that will be filtered, like Tag{}.
+func (f *Filter) Validate(target Filterable) error {
+	err := f.root.validate(target, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid filter %s: %v", f.expression, err)
+	}
+	return nil
+}
+
+func tokenizeFilter(str string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(str)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in %s", str)
+			}
+			value := string(runes[i+1 : end])
+			if r == '"' {
+				unquoted, err := strconv.Unquote(string(runes[i : end+1]))
+				if err != nil {
+					return nil, fmt.Errorf("invalid string %s", string(runes[i:end+1]))
+				}
+				value = unquoted
+			}
+			tokens = append(tokens, filterToken{tokenString, value})
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenNumber, string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_.-/", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenIdent, string(runes[i:end])})
+			i = end
+		default:
+			matched := false
+			for _, op := range filterOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, filterToken{tokenOperator, op})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %c in %s", r, str)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOperator && p.tokens[p.pos].value == op
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.peek("!") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner}, nil
+	}
+	if p.peek("(") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end")
+	}
+	field := p.tokens[p.pos]
+	if field.kind != tokenIdent {
+		return nil, fmt.Errorf("expected a field but got %s", field.value)
+	}
+	p.pos++
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenIdent && p.tokens[p.pos].value == "within" {
+		p.tokens[p.pos].kind = tokenOperator
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOperator || strings.Contains("&&||()!", p.tokens[p.pos].value) {
+		//A field on its own has to be true
+		return &compareNode{field: field.value, op: "==", value: filterLiteral{raw: "true", isBool: true, boolean: true}}, nil
+	}
+	op := p.tokens[p.pos].value
+	p.pos++
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind == tokenOperator {
+		return nil, fmt.Errorf("expected a value after %s %s", field.value, op)
+	}
+	value := parseFilterLiteral(p.tokens[p.pos])
+	p.pos++
+	node := &compareNode{field: field.value, op: op, value: value}
+	if op == "=~" || op == "!~" {
+		var err error
+		node.rx, err = regexp.Compile(value.raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+type filterLiteral struct {
+	raw      string
+	isNumber bool
+	number   float64
+	isAge    bool
+	age      time.Duration
+	//Set for ages with a minus, like -30d, which stand for the time that long ago
+	ago bool
+	isBool   bool
+	boolean  bool
+}
+
+var sizeUnits = map[string]float64{"b": 1, "kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12, "k": 1e3, "m": 1e6}
+
+var numberRx = regexp.MustCompile(`^(-?)([0-9]+(?:\.[0-9]+)?)([a-zA-Z]*)$`)
+
+//parseFilterLiteral parses a value, numbers that aren't sizes or ages like 1.25.3 can still be compared as strings
+func parseFilterLiteral(token filterToken) filterLiteral {
+	lit := filterLiteral{raw: token.value}
+	switch token.kind {
+	case tokenIdent:
+		if token.value == "true" || token.value == "false" {
+			lit.isBool, lit.boolean = true, token.value == "true"
+		}
+	case tokenNumber:
+		if m := numberRx.FindStringSubmatch(token.value); m != nil {
+			number, _ := strconv.ParseFloat(m[1]+m[2], 64)
+			unit := strings.ToLower(m[3])
+			if multiplier, ok := sizeUnits[unit]; ok {
+				lit.isNumber, lit.number = true, number*multiplier
+			} else if unit == "" {
+				lit.isNumber, lit.number = true, number
+			}
+		}
+		//Numbers like 1m can be either a size or an age
+		if age, err := ParseAge(strings.TrimPrefix(token.value, "-")); err == nil {
+			lit.isAge, lit.age, lit.ago = true, age, strings.HasPrefix(token.value, "-")
+		}
+	}
+	return lit
+}
+
+type logicNode struct {
+	and         bool
+	left, right filterNode
+}
+
+func (n *logicNode) eval(target Filterable, now time.Time) (bool, error) {
+	left, err := n.left.eval(target, now)
+	if err != nil || left != n.and {
+		//Short circuit, false for && and true for ||
+		return left, err
+	}
+	return n.right.eval(target, now)
+}
+
+func (n *logicNode) validate(target Filterable, now time.Time) error {
+	err := n.left.validate(target, now)
+	if err != nil {
+		return err
+	}
+	return n.right.validate(target, now)
+}
+
+type notNode struct {
+	inner filterNode
+}
+
+func (n *notNode) eval(target Filterable, now time.Time) (bool, error) {
+	result, err := n.inner.eval(target, now)
+	return !result, err
+}
+
+func (n *notNode) validate(target Filterable, now time.Time) error {
+	return n.inner.validate(target, now)
+}
+
+type compareNode struct {
+	field string
+	op    string
+	value filterLiteral
+	rx    *regexp.Regexp
+}
+
+func (n *compareNode) eval(target Filterable, now time.Time) (bool, error) {
+	value, ok := target.FilterValue(n.field)
+	if !ok {
+		return false, fmt.Errorf("unknown field %s", n.field)
+	}
+	return n.compare(value, now)
+}
+
+func (n *compareNode) validate(target Filterable, now time.Time) error {
+	value, ok := target.FilterValue(n.field)
+	if !ok {
+		return fmt.Errorf("unknown field %s", n.field)
+	}
+	//Empty values skip the comparison, so they're replaced with values that are compared
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			value = []string{""}
+		}
+	case time.Time:
+		if v.IsZero() {
+			value = now
+		}
+	}
+	_, err := n.compare(value, now)
+	return err
+}
+
+func (n *compareNode) compare(value interface{}, now time.Time) (bool, error) {
+	switch v := value.(type) {
+	case string:
+		return n.compareString(v)
+	case []string:
+		matchedAny := false
+		for _, s := range v {
+			matched, err := n.compareString(s)
+			if err != nil {
+				return false, err
+			}
+			//Negated operators have to hold for all the values
+			if (n.op == "!=" || n.op == "!~") && !matched {
+				return false, nil
+			}
+			matchedAny = matchedAny || matched
+		}
+		if n.op == "!=" || n.op == "!~" {
+			return true, nil
+		}
+		return matchedAny, nil
+	case float64:
+		if !n.value.isNumber {
+			return false, fmt.Errorf("%s is a number, not %s", n.field, n.value.raw)
+		}
+		return compareOrdered(n.op, compareFloats(v, n.value.number))
+	case bool:
+		if !n.value.isBool {
+			return false, fmt.Errorf("%s is true or false, not %s", n.field, n.value.raw)
+		}
+		if n.op != "==" && n.op != "!=" {
+			return false, fmt.Errorf("%s can't be compared with %s", n.field, n.op)
+		}
+		return (v == n.value.boolean) == (n.op == "=="), nil
+	case time.Time:
+		if v.IsZero() {
+			return false, nil
+		}
+		if n.op == "within" {
+			if !n.value.isAge {
+				return false, fmt.Errorf("%s within needs an age like 30d, not %s", n.field, n.value.raw)
+			}
+			return !v.Before(now.Add(-n.value.age)), nil
+		}
+		if n.value.isAge && n.value.ago {
+			return compareOrdered(n.op, compareFloats(float64(v.Unix()), float64(now.Add(-n.value.age).Unix())))
+		}
+		if n.value.isAge {
+			//Ages are compared instead of times, so `updated < 30d` means updated in the last 30 days
+			return compareOrdered(n.op, compareFloats(float64(now.Sub(v)), float64(n.value.age)))
+		}
+		date, err := time.Parse("2006-01-02", n.value.raw)
+		if err == nil {
+			//Dates are compared by day, so == matches any time on that day
+			y, m, d := v.UTC().Date()
+			v = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		} else {
+			date, err = time.Parse(time.RFC3339, n.value.raw)
+		}
+		if err != nil {
+			return false, fmt.Errorf("%s is a time, use an age like 30d or a date like \"2020-01-31\", not %s", n.field, n.value.raw)
+		}
+		return compareOrdered(n.op, compareFloats(float64(v.Unix()), float64(date.Unix())))
+	}
+	return false, fmt.Errorf("%s can't be filtered", n.field)
+}
+
+func (n *compareNode) compareString(v string) (bool, error) {
+	switch n.op {
+	case "==":
+		return v == n.value.raw, nil
+	case "!=":
+		return v != n.value.raw, nil
+	case "=~":
+		return n.rx.MatchString(v), nil
+	case "!~":
+		return !n.rx.MatchString(v), nil
+	}
+	return false, fmt.Errorf("%s can't be compared with %s", n.field, n.op)
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareOrdered(op string, result int) (bool, error) {
+	switch op {
+	case "==":
+		return result == 0, nil
+	case "!=":
+		return result != 0, nil
+	case ">":
+		return result > 0, nil
+	case ">=":
+		return result >= 0, nil
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	}
+	return false, fmt.Errorf("numbers and times can't be compared with %s", op)
+}
+
+//FilterValue gets the name, digest, size, updated, updater, arch, os, platform and images fields of a tag.
+func (t Tag) FilterValue(field string) (interface{}, bool) {
+	switch field {
+	case "name":
+		return t.Name, true
+	case "digest":
+		return t.Digest, true
+	case "size":
+		return float64(t.FullSize), true
+	case "updated":
+		return t.LastUpdated, true
+	case "updater":
+		return t.LastUpdaterUsername, true
+	case "images":
+		return float64(len(t.Platforms())), true
+	case "arch", "os", "platform":
+		var values []string
+		for _, p := range t.Platforms() {
+			switch field {
+			case "arch":
+				values = append(values, p.Architecture)
+			case "os":
+				values = append(values, p.OS)
+			default:
+				values = append(values, p.String())
+			}
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+//FilterValue gets the name, namespace, description, pulls, stars, updated, private, automated, type and status fields of a repository.
+func (r Repository) FilterValue(field string) (interface{}, bool) {
+	switch field {
+	case "name":
+		return r.Name, true
+	case "namespace":
+		return r.Namespace, true
+	case "description":
+		return r.Description, true
+	case "pulls":
+		return float64(r.PullCount), true
+	case "stars":
+		return float64(r.StarCount), true
+	case "updated":
+		if r.LastUpdated == nil {
+			return time.Time{}, true
+		}
+		return *r.LastUpdated, true
+	case "private":
+		return r.IsPrivate, true
+	case "automated":
+		return r.IsAutomated, true
+	case "type":
+		return r.RepositoryType, true
+	case "status":
+		return float64(r.Status), true
+	}
+	return nil, false
+}
+
+//FilterValue gets the same fields as a repository's, from a repository listing.
+func (r UserRepository) FilterValue(field string) (interface{}, bool) {
+	return Repository{
+		Name:           r.Name,
+		Namespace:      r.Namespace,
+		RepositoryType: r.RepositoryType,
+		Status:         r.Status,
+		Description:    r.Description,
+		IsPrivate:      r.IsPrivate,
+		IsAutomated:    r.IsAutomated,
+		StarCount:      r.StarCount,
+		PullCount:      r.PullCount,
+		LastUpdated:    r.LastUpdated,
+	}.FilterValue(field)
+}
+
+//FilterValue gets the digest, tags, pushed, pulled and status fields of an image.
+func (i HubImage) FilterValue(field string) (interface{}, bool) {
+	switch field {
+	case "digest":
+		return i.Digest, true
+	case "status":
+		return i.Status, true
+	case "tags":
+		var tags []string
+		for _, t := range i.Tags {
+			tags = append(tags, t.Tag)
+		}
+		return tags, true
+	case "pushed", "pulled":
+		at := i.LastPushed
+		if field == "pulled" {
+			at = i.LastPulled
+		}
+		if at == nil {
+			return time.Time{}, true
+		}
+		return *at, true
+	}
+	return nil, false
+}
+
+//FilterValue gets the name, digest, previous, digests and changed fields of a re-pointed tag.
+func (m TagMutation) FilterValue(field string) (interface{}, bool) {
+	if len(m) == 0 {
+		switch field {
+		case "name", "digest", "previous":
+			return "", true
+		case "digests":
+			return float64(0), true
+		case "changed":
+			return time.Time{}, true
+		}
+		return nil, false
+	}
+	current := m[len(m)-1]
+	switch field {
+	case "name":
+		return current.Tag, true
+	case "digest":
+		return current.Digest, true
+	case "previous":
+		if len(m) < 2 {
+			return "", true
+		}
+		return m[len(m)-2].Digest, true
+	case "digests":
+		return float64(len(m)), true
+	case "changed":
+		return current.FirstSeen, true
+	}
+	return nil, false
+}
+
+//Filter gets the tags that match a filter.
+func (tags TagList) Filter(f *Filter) (TagList, error) {
+	var output TagList
+	for _, t := range tags {
+		matched, err := f.Match(t)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			output = append(output, t)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,101 @@
+package api_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Filter", func() {
+	updated := time.Now().Add(-10 * 24 * time.Hour)
+	repo := api.Repository{Name: "svc-api", Namespace: "user", PullCount: 5000, IsPrivate: true, LastUpdated: &updated}
+	tag := api.Tag{
+		Name:        "1.25.3",
+		FullSize:    250 * 1000 * 1000,
+		LastUpdated: updated,
+		Images:      []api.TaggedImage{taggedImage("linux", "amd64", ""), taggedImage("linux", "arm64", "v8")},
+	}
+
+	match := func(expression string, target api.Filterable) bool {
+		f, err := api.ParseFilter(expression)
+		Expect(err).NotTo(HaveOccurred(), expression)
+		matched, err := f.Match(target)
+		Expect(err).NotTo(HaveOccurred(), expression)
+		return matched
+	}
+
+	It("should filter repositories", func() {
+		Expect(match(`pulls > 1000 && updated < 30d && name =~ "^svc-"`, repo)).To(BeTrue())
+		Expect(match(`pulls > 1000 && updated < 7d`, repo)).To(BeFalse())
+		Expect(match(`updated > 7d && updated <= 30d`, repo)).To(BeTrue())
+		Expect(match(`updated within 30d && !(updated within 7d)`, repo)).To(BeTrue())
+		Expect(match(`updated > -30d && updated < -7d && updated < "2100-01-01"`, repo)).To(BeTrue())
+		Expect(match(`private`, repo)).To(BeTrue())
+		Expect(match(`!private || stars >= 1`, repo)).To(BeFalse())
+		Expect(match(`(name == other || namespace == user) && updated > "2001-01-01"`, repo)).To(BeTrue())
+		Expect(match(`name !~ 'svc'`, repo)).To(BeFalse())
+	})
+
+	It("should filter tags", func() {
+		Expect(match(`size > 200MB && arch == "arm64"`, tag)).To(BeTrue())
+		Expect(match(`size > 1GB`, tag)).To(BeFalse())
+		Expect(match(`arch != arm64`, tag)).To(BeFalse())
+		Expect(match(`arch != riscv64 && platform == linux/arm64/v8`, tag)).To(BeTrue())
+		Expect(match(`name == 1.25.3 && images == 2`, tag)).To(BeTrue())
+		tags, err := api.TagList{tag, {Name: "small", FullSize: 10}}.Filter(mustParseFilter(`size < 1KB`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tags).To(HaveLen(1))
+	})
+
+	It("should filter tag mutations", func() {
+		mutation := api.TagMutation{
+			{Tag: "latest", Digest: "sha256:a", FirstSeen: updated.Add(-time.Hour)},
+			{Tag: "latest", Digest: "sha256:b", FirstSeen: updated},
+		}
+		Expect(match(`name == latest && digests == 2 && previous == "sha256:a"`, mutation)).To(BeTrue())
+		Expect(match(`changed < 7d`, mutation)).To(BeFalse())
+		Expect(mustParseFilter(`digest == "sha256:b" && changed < 1d`).Validate(api.TagMutation{})).To(Succeed())
+	})
+
+	It("should compare dates by day", func() {
+		at := time.Date(2020, 1, 31, 15, 4, 5, 0, time.UTC)
+		target := api.Tag{LastUpdated: at}
+		Expect(match(`updated == "2020-01-31"`, target)).To(BeTrue())
+		Expect(match(`updated < "2020-01-31" || updated > "2020-01-31"`, target)).To(BeFalse())
+		Expect(match(`updated >= "2020-01-31" && updated < "2020-02-01"`, target)).To(BeTrue())
+		Expect(match(`updated == "2020-01-31T15:04:05Z"`, target)).To(BeTrue())
+	})
+
+	It("should report invalid filters", func() {
+		for _, expression := range []string{`pulls >`, `(pulls > 1`, `name == "a`, `pulls > 1 pulls`, `name =~ "("`, `== 1`} {
+			_, err := api.ParseFilter(expression)
+			Expect(err).To(HaveOccurred(), expression)
+		}
+		for _, expression := range []string{`stars > many`, `unknown == 1`, `private > 1`, `updated < soon`, `updated within "2020-01-31"`, `pulls within 1d`} {
+			f, err := api.ParseFilter(expression)
+			Expect(err).NotTo(HaveOccurred(), expression)
+			_, err = f.Match(repo)
+			Expect(err).To(HaveOccurred(), expression)
+		}
+	})
+
+	It("should validate every field before filtering", func() {
+		for _, expression := range []string{`sise > 200MB`, `size < 1GB && sise > 200MB`, `!(arch > 1)`, `updated < "soon"`} {
+			f, err := api.ParseFilter(expression)
+			Expect(err).NotTo(HaveOccurred(), expression)
+			Expect(f.Validate(api.Tag{})).NotTo(Succeed(), expression)
+		}
+		f, err := api.ParseFilter(`size > 200MB && arch == "arm64" || updated < 30d || updated > "2020-01-31"`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Validate(api.Tag{})).To(Succeed())
+		Expect(f.Validate(api.UserRepository{})).NotTo(Succeed())
+	})
+})
+
+func mustParseFilter(expression string) *api.Filter {
+	f, err := api.ParseFilter(expression)
+	Expect(err).NotTo(HaveOccurred())
+	return f
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//TagDigest is a period in which a tag was seen pointing to a digest.
+type TagDigest struct {
+	Tag       string    `json:"tag"`
+	Digest    string    `json:"digest"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+//RepositoryHistory is every digest that the tags of a repository were seen pointing to, oldest first.
+type RepositoryHistory struct {
+	Entries []TagDigest `json:"entries"`
+}
+
+//HistoryStore keeps the history of repositories in a directory, a file for each repository.
+type HistoryStore struct {
+	Dir string
+}
+
+func (h *HistoryStore) path(repository string) string {
+	return filepath.Join(h.Dir, filepath.FromSlash(repository)+".json")
+}
+
+//Get reads the history of a repository, which is empty if it was never recorded.
+func (h *HistoryStore) Get(repository string) (*RepositoryHistory, error) {
+	var history RepositoryHistory
+	content, err := ioutil.ReadFile(h.path(repository))
+	if os.IsNotExist(err) {
+		return &history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &history)
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+//Record adds the digests that the tags point to now to the history of a repository.
+func (h *HistoryStore) Record(repository string, tags TagList, now time.Time) error {
+	history, err := h.Get(repository)
+	if err != nil {
+		return err
+	}
+	history.Observe(tags, now)
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	path := h.path(repository)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".tmp", content, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+//Observe records the digests that the tags point to at the given time.
+//Tags that still point to the same digest only have their last seen time updated.
+func (r *RepositoryHistory) Observe(tags TagList, now time.Time) {
+	latest := make(map[string]int)
+	for i, e := range r.Entries {
+		latest[e.Tag] = i
+	}
+	for _, t := range tags {
+		if t.Digest == "" {
+			continue
+		}
+		if i, ok := latest[t.Name]; ok && r.Entries[i].Digest == t.Digest {
+			if now.After(r.Entries[i].LastSeen) {
+				r.Entries[i].LastSeen = now
+			}
+			continue
+		}
+		r.Entries = append(r.Entries, TagDigest{Tag: t.Name, Digest: t.Digest, FirstSeen: now, LastSeen: now})
+		latest[t.Name] = len(r.Entries) - 1
+	}
+}
+
+//Tag gets the digests that a tag was seen pointing to, oldest first.
+func (r *RepositoryHistory) Tag(name string) []TagDigest {
+	var output []TagDigest
+	for _, e := range r.Entries {
+		if e.Tag == name {
+			output = append(output, e)
+		}
+	}
+	return output
+}
+
+//Mutations gets the history of each tag that was seen pointing to more than one digest.
+func (r *RepositoryHistory) Mutations() map[string][]TagDigest {
+	output := make(map[string][]TagDigest)
+	for _, e := range r.Entries {
+		output[e.Tag] = append(output[e.Tag], e)
+	}
+	for tag, entries := range output {
+		if len(entries) < 2 {
+			delete(output, tag)
+		}
+	}
+	return output
+}
+
+//TagMutation is the history of a tag that was seen pointing to more than one digest, oldest first.
+type TagMutation []TagDigest
+
+//MutatedTags gets the names of the tags that were re-pointed, the most recently changed first and then by name.
+func (r *RepositoryHistory) MutatedTags() []string {
+	mutations := r.Mutations()
+	var names []string
+	for tag := range mutations {
+		names = append(names, tag)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := mutations[names[i]][len(mutations[names[i]])-1], mutations[names[j]][len(mutations[names[j]])-1]
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return a.FirstSeen.After(b.FirstSeen)
+		}
+		//Tags that moved together are sorted by name, so the order doesn't change between runs
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("History", func() {
+	var store *api.HistoryStore
+	day1 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	day3 := day2.Add(24 * time.Hour)
+
+	BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "history")
+		Expect(err).NotTo(HaveOccurred())
+		store = &api.HistoryStore{Dir: dir}
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(store.Dir)
+	})
+
+	It("should record when tags move", func() {
+		Expect(store.Record("user/repo", api.TagList{{Name: "latest", Digest: "sha256:a"}, {Name: "1.0", Digest: "sha256:a"}}, day1)).To(Succeed())
+		Expect(store.Record("user/repo", api.TagList{{Name: "latest", Digest: "sha256:a"}, {Name: "1.0", Digest: "sha256:a"}}, day2)).To(Succeed())
+		Expect(store.Record("user/repo", api.TagList{{Name: "latest", Digest: "sha256:b"}, {Name: "1.0", Digest: "sha256:a"}}, day3)).To(Succeed())
+		history, err := store.Get("user/repo")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.Tag("latest")).To(Equal([]api.TagDigest{
+			{Tag: "latest", Digest: "sha256:a", FirstSeen: day1, LastSeen: day2},
+			{Tag: "latest", Digest: "sha256:b", FirstSeen: day3, LastSeen: day3},
+		}))
+		Expect(history.Tag("1.0")).To(HaveLen(1))
+		Expect(history.MutatedTags()).To(Equal([]string{"latest"}))
+	})
+
+	It("should sort tags that moved together by name", func() {
+		var before, after api.TagList
+		for _, name := range []string{"latest", "1.25", "1", "1.24"} {
+			before = append(before, api.Tag{Name: name, Digest: "sha256:a"})
+			after = append(after, api.Tag{Name: name, Digest: "sha256:b"})
+		}
+		Expect(store.Record("user/repo", before, day1)).To(Succeed())
+		Expect(store.Record("user/repo", after[:1], day2)).To(Succeed())
+		Expect(store.Record("user/repo", after, day3)).To(Succeed())
+		history, err := store.Get("user/repo")
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10; i++ {
+			Expect(history.MutatedTags()).To(Equal([]string{"1", "1.24", "1.25", "latest"}))
+		}
+	})
+
+	It("should have no history for unknown repositories", func() {
+		history, err := store.Get("user/other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.Entries).To(BeEmpty())
+	})
+})
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/requests"
+	"strings"
+	"time"
+)
+
+//HubImage is a manifest in a docker hub repository, with the tags that point or pointed to it.
+type HubImage struct {
+	Namespace  string        `json:"namespace"`
+	Repository string        `json:"repository"`
+	Digest     string        `json:"digest"`
+	Tags       []HubImageTag `json:"tags"`
+	LastPushed *time.Time    `json:"last_pushed"`
+	LastPulled *time.Time    `json:"last_pulled"`
+	Status     string        `json:"status"`
+}
+
+type HubImageTag struct {
+	Tag string `json:"tag"`
+	//Whether the tag still points to the image
+	IsCurrent bool `json:"is_current"`
+}
+
+//ImageDeletion is the result of deleting images from docker hub.
+type ImageDeletion struct {
+	ManifestDeletes int `json:"manifest_deletes"`
+	ManifestErrors  int `json:"manifest_errors"`
+	TagDeletes      int `json:"tag_deletes"`
+	TagErrors       int `json:"tag_errors"`
+}
+
+type imageDeletionRequest struct {
+	DryRun         bool                    `json:"dry_run"`
+	Manifests      []imageDeletionManifest `json:"manifests"`
+	IgnoreWarnings []ImageDeletionWarning  `json:"ignore_warnings,omitempty"`
+}
+
+type imageDeletionManifest struct {
+	Repository string `json:"repository"`
+	Digest     string `json:"digest"`
+}
+
+//ImageDeletionWarning is a reason docker hub gives for not deleting a manifest, it's deleted once the warning is acknowledged.
+type ImageDeletionWarning struct {
+	Repository string `json:"repository"`
+	Digest     string `json:"digest"`
+	//current_tag if tags still point to the manifest, is_active if it was pulled recently
+	Warning string `json:"warning"`
+	//The tags that point to the manifest, needed to acknowledge current_tag warnings
+	Tags []string `json:"tags,omitempty"`
+}
+
+//ImageDeletionWarnings is returned when docker hub won't delete manifests until its warnings are acknowledged.
+type ImageDeletionWarnings []ImageDeletionWarning
+
+func (w ImageDeletionWarnings) Error() string {
+	var messages []string
+	for _, warning := range w {
+		messages = append(messages, fmt.Sprintf("%s: %s", warning.Digest, warning.Warning))
+	}
+	return "docker hub warns about " + strings.Join(messages, ", ")
+}
+
+type imageDeletionErrors struct {
+	ErrInfo struct {
+		Details struct {
+			Errors []struct {
+				Repository string   `json:"repository"`
+				Digest     string   `json:"digest"`
+				Warnings   []string `json:"warnings"`
+			} `json:"errors"`
+		} `json:"details"`
+	} `json:"errinfo"`
+}
+
+//parseImageDeletionWarnings gets the warnings from a failed delete-images response
+func parseImageDeletionWarnings(body []byte) ImageDeletionWarnings {
+	var data imageDeletionErrors
+	if json.Unmarshal(body, &data) != nil {
+		return nil
+	}
+	var warnings ImageDeletionWarnings
+	for _, e := range data.ErrInfo.Details.Errors {
+		for _, warning := range e.Warnings {
+			warnings = append(warnings, ImageDeletionWarning{Repository: e.Repository, Digest: e.Digest, Warning: warning})
+		}
+	}
+	return warnings
+}
+
+//GetUntaggedImages gets the manifests of a repository that no tag points to.
+func (d *DockerApi) GetUntaggedImages(username, name string) ([]HubImage, error) {
+	if username == "" {
+		return nil, fmt.Errorf("no user given")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no image name given")
+	}
+	username = strings.ToLower(username)
+	var output []HubImage
+	for page := 1; ; page++ {
+		pth := d.getRoute(fmt.Sprintf("namespaces/%s/repositories/%s/images?currently_tagged=false&page_size=100&page=%v", username, name, page))
+		r, err := requests.Get(d.client, pth, d.token)
+		if err != nil {
+			return nil, hubError(err, r)
+		}
+		var search SearchResult
+		err = json.Unmarshal(r, &search)
+		if err != nil {
+			return nil, err
+		}
+		var images []HubImage
+		if search.Results != nil {
+			err = json.Unmarshal(search.Results, &images)
+			if err != nil {
+				return nil, err
+			}
+		}
+		output = append(output, images...)
+		if search.Next == nil {
+			return output, nil
+		}
+	}
+}
+
+//DeleteImages deletes manifests from a docker hub repository by digest, along with any tags that point to them.
+//Manifests that are still tagged or in use are only deleted if their warnings are in ignore,
+//otherwise nothing is deleted and ImageDeletionWarnings is returned.
+func (d *DockerApi) DeleteImages(username, name string, ignore ImageDeletionWarnings, digests ...string) (*ImageDeletion, error) {
+	if username == "" {
+		return nil, fmt.Errorf("no user given")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no image name given")
+	}
+	if len(digests) == 0 {
+		return nil, fmt.Errorf("no digests given")
+	}
+	username = strings.ToLower(username)
+	data := imageDeletionRequest{}
+	for _, digest := range digests {
+		if !IsDigest(digest) {
+			return nil, fmt.Errorf("invalid digest %s", digest)
+		}
+		data.Manifests = append(data.Manifests, imageDeletionManifest{Repository: name, Digest: digest})
+	}
+	data.IgnoreWarnings = ignore
+	pth := d.getRoute(fmt.Sprintf("namespaces/%s/delete-images", username))
+	r, err := requests.Post(d.client, pth, data, d.token)
+	if err != nil {
+		if warnings := parseImageDeletionWarnings(r); len(warnings) > 0 {
+			return nil, warnings
+		}
+		return nil, hubError(err, r)
+	}
+	var result struct {
+		Metrics ImageDeletion `json:"metrics"`
+	}
+	err = json.Unmarshal(r, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Metrics, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+//ContainerConfig is the default configuration for containers that run an image.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+}
+
+//ImageConfig is the configuration blob of an image.
+type ImageConfig struct {
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	OSVersion    string          `json:"os.version,omitempty"`
+	Variant      string          `json:"variant,omitempty"`
+	Created      *time.Time      `json:"created,omitempty"`
+	Config       ContainerConfig `json:"config"`
+}
+
+//Platform gets the platform the image was built for.
+func (c *ImageConfig) Platform() *Platform {
+	return &Platform{Architecture: c.Architecture, OS: c.OS, OSVersion: c.OSVersion, Variant: c.Variant}
+}
+
+//Image is a single platform image, with its manifest and config.
+type Image struct {
+	Repository string
+	Digest     string
+	Manifest   *Manifest
+	Config     *ImageConfig
+}
+
+//Size gets the compressed size of all the layers of the image.
+func (i *Image) Size() int64 {
+	var size int64
+	for _, l := range i.Manifest.Layers {
+		size += l.Size
+	}
+	return size
+}
+
+//GetBlob downloads a blob and verifies its digest.
+func (rc *RegistryClient) GetBlob(repo, digest string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rc.getRoute(repo, "blobs", digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := rc.do(req, pullScope(repo))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if digestOf(content) != digest {
+		return nil, fmt.Errorf("blob digest mismatch, expected %s but got %s", digest, digestOf(content))
+	}
+	return content, nil
+}
+
+//ResolvePlatform resolves a manifest to the image for the given platform, if it's an index.
+//Single platform manifests are returned as they are.
+func (rc *RegistryClient) ResolvePlatform(repo string, manifest *RawManifest, platform *Platform) (*RawManifest, error) {
+	parsed, err := manifest.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.IsIndex() {
+		return manifest, nil
+	}
+	for _, m := range parsed.Manifests {
+		if m.Platform != nil && m.Platform.Matches(platform) {
+			return rc.GetManifest(repo, m.Digest)
+		}
+	}
+	return nil, fmt.Errorf("no image found for platform %s", platform)
+}
+
+//GetImage gets the manifest and config of an image, resolving indexes to the given platform.
+func (rc *RegistryClient) GetImage(repo, reference string, platform *Platform) (*Image, error) {
+	manifest, err := rc.GetManifest(repo, reference)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err = rc.ResolvePlatform(repo, manifest, platform)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := manifest.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Config == nil {
+		return nil, fmt.Errorf("manifest %s has no config", manifest.Digest)
+	}
+	content, err := rc.GetBlob(repo, parsed.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	var config ImageConfig
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{
+		Repository: repo,
+		Digest:     manifest.Digest,
+		Manifest:   parsed,
+		Config:     &config,
+	}, nil
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const refNameAnnotation = "org.opencontainers.image.ref.name"
+
+//ImageLayout is an OCI image layout directory.
+type ImageLayout struct {
+	Path string
+}
+
+//dockerArchiveManifest is an entry of the manifest.json file that `docker load` uses
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+//NewImageLayout creates an OCI image layout in the given directory, or opens it if it already exists.
+func NewImageLayout(path string) (*ImageLayout, error) {
+	l := &ImageLayout{Path: path}
+	err := os.MkdirAll(filepath.Join(path, "blobs", "sha256"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(path, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(l.indexPath()); os.IsNotExist(err) {
+		err = l.writeIndex(&Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Manifests: []Descriptor{}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *ImageLayout) indexPath() string {
+	return filepath.Join(l.Path, "index.json")
+}
+
+func (l *ImageLayout) blobPath(digest string) string {
+	parts := strings.SplitN(digest, ":", 2)
+	return filepath.Join(l.Path, "blobs", parts[0], parts[len(parts)-1])
+}
+
+//HasBlob checks if the layout already contains a blob.
+func (l *ImageLayout) HasBlob(digest string) bool {
+	_, err := os.Stat(l.blobPath(digest))
+	return err == nil
+}
+
+//ReadBlob reads a blob from the layout.
+func (l *ImageLayout) ReadBlob(digest string) ([]byte, error) {
+	return ioutil.ReadFile(l.blobPath(digest))
+}
+
+//WriteBlob stores content in the layout, returning its digest.
+func (l *ImageLayout) WriteBlob(content []byte) (string, error) {
+	digest := digestOf(content)
+	return digest, ioutil.WriteFile(l.blobPath(digest), content, 0644)
+}
+
+//Index reads the index.json of the layout.
+func (l *ImageLayout) Index() (*Manifest, error) {
+	content, err := ioutil.ReadFile(l.indexPath())
+	if err != nil {
+		return nil, err
+	}
+	var index Manifest
+	err = json.Unmarshal(content, &index)
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+func (l *ImageLayout) writeIndex(index *Manifest) error {
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(l.indexPath(), content, 0644)
+}
+
+//AddManifest adds a manifest to the index of the layout, replacing any manifest with the same ref name.
+func (l *ImageLayout) AddManifest(desc Descriptor, refName string) error {
+	index, err := l.Index()
+	if err != nil {
+		return err
+	}
+	if refName != "" {
+		desc.Annotations = map[string]string{refNameAnnotation: refName}
+	}
+	var manifests []Descriptor
+	for _, m := range index.Manifests {
+		if refName == "" || m.Annotations[refNameAnnotation] != refName {
+			manifests = append(manifests, m)
+		}
+	}
+	index.Manifests = append(manifests, desc)
+	return l.writeIndex(index)
+}
+
+//AddDockerManifest adds a manifest.json file so that `docker load` can load the given single platform image.
+func (l *ImageLayout) AddDockerManifest(repoTag string, manifest *Manifest) error {
+	if manifest.IsIndex() || manifest.Config == nil {
+		return fmt.Errorf("docker archives need a single platform image")
+	}
+	entry := dockerArchiveManifest{
+		Config:   l.relativeBlobPath(manifest.Config.Digest),
+		RepoTags: []string{},
+	}
+	if repoTag != "" {
+		entry.RepoTags = append(entry.RepoTags, repoTag)
+	}
+	for _, layer := range manifest.Layers {
+		entry.Layers = append(entry.Layers, l.relativeBlobPath(layer.Digest))
+	}
+	content, err := json.Marshal([]dockerArchiveManifest{entry})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(l.Path, "manifest.json"), content, 0644)
+}
+
+func (l *ImageLayout) relativeBlobPath(digest string) string {
+	rel, _ := filepath.Rel(l.Path, l.blobPath(digest))
+	return filepath.ToSlash(rel)
+}
+
+//WriteTar writes the whole layout as a tarball.
+func (l *ImageLayout) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(l.Path, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.Path, pth)
+		if err != nil || rel == "." || strings.HasSuffix(rel, partialSuffix) {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		err = tw.WriteHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		f, err := os.Open(pth)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+//OpenBlob opens a blob of the layout for reading.
+func (l *ImageLayout) OpenBlob(digest string) (*os.File, error) {
+	return os.Open(l.blobPath(digest))
+}
+
+//ExtractLayoutTar extracts an image layout tarball into the given directory.
+func ExtractLayoutTar(tarPath, dir string) (*ImageLayout, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("invalid path in tarball: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = extractFile(reader, target)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "oci-layout")); err != nil {
+		return nil, fmt.Errorf("%s is not an OCI image layout", tarPath)
+	}
+	return &ImageLayout{Path: dir}, nil
+}
+
+func extractFile(r io.Reader, target string) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	closeErr := out.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+//FindManifest finds the manifest with the given ref name in the index of the layout.
+//If no ref name is given then the layout has to contain a single manifest.
+func (l *ImageLayout) FindManifest(refName string) (*Descriptor, error) {
+	index, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+	var refNames []string
+	for i, m := range index.Manifests {
+		name := m.Annotations[refNameAnnotation]
+		if refName != "" && name == refName {
+			return &index.Manifests[i], nil
+		}
+		refNames = append(refNames, name)
+	}
+	if len(index.Manifests) == 1 && (refName == "" || refNames[0] == "") {
+		return &index.Manifests[0], nil
+	}
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("the image layout has no manifests")
+	}
+	return nil, fmt.Errorf("could not find manifest %s in the image layout, available: %s", refName, strings.Join(refNames, ", "))
+}
+
+//SelectManifest picks the manifest to push from the layout.
+//The ref name is used if it's given, otherwise a single manifest is used whatever its ref name is,
+//and layouts with several manifests fall back to the manifest named like the target tag.
+func (l *ImageLayout) SelectManifest(refName, targetTag string) (*Descriptor, error) {
+	if refName != "" {
+		return l.FindManifest(refName)
+	}
+	index, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+	if len(index.Manifests) == 1 {
+		return &index.Manifests[0], nil
+	}
+	return l.FindManifest(targetTag)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+//manifestMediaTypes are all the manifest types we accept from a registry
+var manifestMediaTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}
+
+type Platform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	//Architecture variant - v7 for example
+	Variant string `json:"variant,omitempty"`
+}
+
+func (p *Platform) String() string {
+	str := fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+	if p.Variant != "" {
+		str += "/" + p.Variant
+	}
+	return str
+}
+
+//Descriptor describes content stored in a registry, like a manifest, a config or a layer.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	URLs         []string          `json:"urls,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+}
+
+//Manifest is either an image manifest or an index (manifest list) pointing to other manifests.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []Descriptor      `json:"layers,omitempty"`
+	Manifests     []Descriptor      `json:"manifests,omitempty"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+//IsIndex checks if the manifest is an index or a manifest list.
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+//RawManifest is a manifest exactly as it was served by the registry.
+//The content is kept byte for byte so that it can be pushed again without changing its digest.
+type RawManifest struct {
+	MediaType string
+	Digest    string
+	Content   []byte
+}
+
+//Parse parses the content of the manifest.
+func (r *RawManifest) Parse() (*Manifest, error) {
+	var m Manifest
+	err := json.Unmarshal(r.Content, &m)
+	if err != nil {
+		return nil, err
+	}
+	if m.MediaType == "" {
+		m.MediaType = r.MediaType
+	}
+	return &m, nil
+}
+
+//Descriptor gets a descriptor pointing to this manifest.
+func (r *RawManifest) Descriptor() Descriptor {
+	return Descriptor{
+		MediaType: r.MediaType,
+		Digest:    r.Digest,
+		Size:      int64(len(r.Content)),
+	}
+}
+
+//digestOf computes the sha256 digest of the given content
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+//detectManifestType figures out the media type of a manifest from its content, for registries that don't send it.
+func detectManifestType(content []byte) string {
+	var m Manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return ""
+	}
+	if m.MediaType != "" {
+		return m.MediaType
+	}
+	if m.Manifests != nil {
+		return MediaTypeOCIIndex
+	}
+	return MediaTypeOCIManifest
+}
+
+//ParsePlatform parses platforms like linux/amd64 or linux/arm64/v8.
+func ParsePlatform(str string) (*Platform, error) {
+	parts := strings.Split(strings.ToLower(str), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid platform %s, expected os/arch[/variant]", str)
+	}
+	p := &Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+//normalizedVariant treats the default variants of an architecture as if no variant was given
+func (p *Platform) normalizedVariant() string {
+	if (p.Architecture == "arm64" && p.Variant == "v8") || (p.Architecture == "arm" && p.Variant == "v7") {
+		return ""
+	}
+	return p.Variant
+}
+
+//Normalized gets a copy of the platform without the default variant of its architecture,
+//so `linux/arm64/v8` and `linux/arm64` are the same platform.
+func (p *Platform) Normalized() *Platform {
+	return &Platform{OS: p.OS, Architecture: p.Architecture, Variant: p.normalizedVariant()}
+}
+
+//Matches checks if this platform satisfies the wanted one. The variant is only compared if the wanted platform has one.
+func (p *Platform) Matches(wanted *Platform) bool {
+	if p.OS != wanted.OS || p.Architecture != wanted.Architecture {
+		return false
+	}
+	return wanted.Variant == "" || p.normalizedVariant() == wanted.normalizedVariant()
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//IndexEntry is an existing single platform image that is added to an index.
+type IndexEntry struct {
+	Repository string
+	Reference  string
+	//Overrides the variant read from the image config
+	Variant string
+	//Overrides the os version read from the image config
+	OSVersion  string
+	OSFeatures []string
+}
+
+//Annotate sets a platform field of the entry, one of variant, os.version or os.features.
+func (e *IndexEntry) Annotate(key, value string) error {
+	switch key {
+	case "variant":
+		e.Variant = value
+	case "os.version":
+		e.OSVersion = value
+	case "os.features":
+		e.OSFeatures = strings.Split(value, ",")
+	default:
+		return fmt.Errorf("unknown platform field %s, only variant, os.version and os.features can be set", key)
+	}
+	return nil
+}
+
+//CreateIndex assembles an index from single platform images and pushes it under the given tag.
+//The platform of each image is read from its config. If all the images are docker manifests then
+//a docker manifest list is created, otherwise an OCI index.
+//Images from other repositories are copied into the repository first.
+func (rc *RegistryClient) CreateIndex(repo, tag string, entries []IndexEntry) (*RawManifest, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no images given")
+	}
+	index := Manifest{SchemaVersion: 2, MediaType: MediaTypeDockerManifestList}
+	platforms := make(map[string]string)
+	for _, entry := range entries {
+		desc, err := rc.indexDescriptor(repo, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%s: %v", entry.Repository, entry.Reference, err)
+		}
+		key := desc.Platform.String() + " " + desc.Platform.OSVersion
+		if other, ok := platforms[key]; ok {
+			return nil, fmt.Errorf("%s:%s and %s have the same platform %s", entry.Repository, entry.Reference, other, desc.Platform)
+		}
+		platforms[key] = entry.Repository + ":" + entry.Reference
+		if desc.MediaType != MediaTypeDockerManifest {
+			index.MediaType = MediaTypeOCIIndex
+		}
+		index.Manifests = append(index.Manifests, *desc)
+	}
+	content, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &RawManifest{MediaType: index.MediaType, Digest: digestOf(content), Content: content}
+	digest, err := rc.PutManifest(repo, tag, manifest.MediaType, manifest.Content)
+	if err != nil {
+		return nil, err
+	}
+	if digest != manifest.Digest {
+		return nil, fmt.Errorf("registry stored %s:%s as %s instead of %s", repo, tag, digest, manifest.Digest)
+	}
+	return manifest, nil
+}
+
+//indexDescriptor gets the descriptor of an image for an index, making sure that the image is in the repository
+func (rc *RegistryClient) indexDescriptor(repo string, entry IndexEntry) (*Descriptor, error) {
+	image, err := rc.GetManifest(entry.Repository, entry.Reference)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := image.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if parsed.IsIndex() {
+		return nil, fmt.Errorf("is already a multi platform image")
+	}
+	if parsed.Config == nil {
+		return nil, fmt.Errorf("manifest %s has no config", image.Digest)
+	}
+	content, err := rc.GetBlob(entry.Repository, parsed.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	var config ImageConfig
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, err
+	}
+	platform := config.Platform()
+	if entry.Variant != "" {
+		platform.Variant = entry.Variant
+	}
+	if entry.OSVersion != "" {
+		platform.OSVersion = entry.OSVersion
+	}
+	if len(entry.OSFeatures) > 0 {
+		platform.OSFeatures = entry.OSFeatures
+	}
+	if platform.OS == "" || platform.Architecture == "" {
+		return nil, fmt.Errorf("the image config has no platform")
+	}
+	if entry.Repository != repo {
+		err = rc.copyImage(entry.Repository, repo, image, parsed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	desc := image.Descriptor()
+	desc.Platform = platform
+	return &desc, nil
+}
+
+//copyImage copies an image with its blobs to another repository of the registry, by digest
+func (rc *RegistryClient) copyImage(from, to string, image *RawManifest, parsed *Manifest) error {
+	blobs := append([]Descriptor{*parsed.Config}, parsed.Layers...)
+	for _, blob := range blobs {
+		reader, _, err := rc.OpenBlob(from, blob.Digest, 0)
+		if err != nil {
+			return err
+		}
+		_, err = rc.UploadBlob(to, blob, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("could not copy %s: %v", blob.Digest, err)
+		}
+	}
+	_, err := rc.PutManifest(to, image.Digest, image.MediaType, image.Content)
+	return err
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("CreateIndex", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should create an index from per platform tags", func() {
+		amd := pushImage(registry, "user/repo", "1.0-amd64", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "amd")
+		arm := pushImage(registry, "user/repo", "1.0-arm64", api.ImageConfig{Architecture: "arm64", OS: "linux"}, "arm")
+		entries := []api.IndexEntry{
+			{Repository: "user/repo", Reference: "1.0-amd64"},
+			{Repository: "user/repo", Reference: "1.0-arm64"},
+		}
+		Expect(entries[1].Annotate("variant", "v8")).To(Succeed())
+		Expect(entries[1].Annotate("arch", "arm")).NotTo(Succeed())
+		manifest, err := client.CreateIndex("user/repo", "1.0", entries)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.MediaType).To(Equal(api.MediaTypeOCIIndex))
+
+		arm64, _ := api.ParsePlatform("linux/arm64/v8")
+		image, err := client.GetImage("user/repo", "1.0", arm64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.Digest).To(Equal(arm))
+		parsed, _ := manifest.Parse()
+		Expect(parsed.Manifests[0].Digest).To(Equal(amd))
+		Expect(parsed.Manifests[1].Platform.Variant).To(Equal("v8"))
+	})
+
+	It("should copy images from other repositories", func() {
+		amd := pushImage(registry, "user/build", "amd64", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "amd")
+		_, err := client.CreateIndex("user/repo", "1.0", []api.IndexEntry{{Repository: "user/build", Reference: "amd64"}})
+		Expect(err).NotTo(HaveOccurred())
+		amd64, _ := api.ParsePlatform("linux/amd64")
+		image, err := client.GetImage("user/repo", "1.0", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.Digest).To(Equal(amd))
+	})
+
+	It("should reject duplicate platforms", func() {
+		pushImage(registry, "user/repo", "a", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "a")
+		pushImage(registry, "user/repo", "b", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "b")
+		_, err := client.CreateIndex("user/repo", "1.0", []api.IndexEntry{
+			{Repository: "user/repo", Reference: "a"},
+			{Repository: "user/repo", Reference: "b"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
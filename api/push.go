@@ -0,0 +1,184 @@
+package api
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+//DefaultChunkSize is the size of the chunks that big blobs are uploaded in.
+const DefaultChunkSize = 32 * 1024 * 1024
+
+//HasBlob checks if the registry already has a blob in the given repository.
+func (rc *RegistryClient) HasBlob(repo, digest string) (bool, error) {
+	req, err := http.NewRequest("HEAD", rc.getRoute(repo, "blobs", digest), nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := rc.do(req, pushScope(repo))
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	return true, nil
+}
+
+//resolveLocation resolves the upload location the registry sent, which may be relative.
+func (rc *RegistryClient) resolveLocation(res *http.Response) (*url.URL, error) {
+	location := res.Header.Get("Location")
+	if location == "" {
+		return nil, fmt.Errorf("registry sent no upload location")
+	}
+	return res.Request.URL.Parse(location)
+}
+
+//UploadBlob uploads a blob, unless the registry already has it.
+//Blobs bigger than the chunk size are uploaded in chunks, smaller ones in a single request.
+//Returns true if the blob was uploaded.
+func (rc *RegistryClient) UploadBlob(repo string, desc Descriptor, content io.Reader) (bool, error) {
+	exists, err := rc.HasBlob(repo, desc.Digest)
+	if err != nil || exists {
+		return false, err
+	}
+	req, err := http.NewRequest("POST", rc.getRoute(repo, "blobs", "uploads")+"/", nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := rc.do(req, pushScope(repo))
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	location, err := rc.resolveLocation(res)
+	if err != nil {
+		return false, err
+	}
+	chunkSize := rc.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	var body io.Reader = content
+	size := desc.Size
+	if desc.Size > chunkSize {
+		location, err = rc.uploadChunks(repo, location, desc.Size, chunkSize, content)
+		if err != nil {
+			return false, err
+		}
+		body, size = nil, 0
+	}
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+	req, err = http.NewRequest("PUT", location.String(), body)
+	if err != nil {
+		return false, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err = rc.do(req, pushScope(repo))
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	return true, nil
+}
+
+func (rc *RegistryClient) uploadChunks(repo string, location *url.URL, size, chunkSize int64, content io.Reader) (*url.URL, error) {
+	for offset := int64(0); offset < size; offset += chunkSize {
+		length := chunkSize
+		if offset+length > size {
+			length = size - offset
+		}
+		req, err := http.NewRequest("PATCH", location.String(), io.LimitReader(content, length))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = length
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+length-1))
+		res, err := rc.do(req, pushScope(repo))
+		if err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+		location, err = rc.resolveLocation(res)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return location, nil
+}
+
+//PushImage uploads a manifest from an image layout with all the blobs and manifests it references.
+//The manifest is tagged with the given tag, the manifests it references are pushed by digest.
+func (rc *RegistryClient) PushImage(layout *ImageLayout, desc Descriptor, repo, tag string) error {
+	content, err := layout.ReadBlob(desc.Digest)
+	if err != nil {
+		return err
+	}
+	if digestOf(content) != desc.Digest {
+		return fmt.Errorf("manifest %s is corrupted", desc.Digest)
+	}
+	manifest := &RawManifest{MediaType: desc.MediaType, Digest: desc.Digest, Content: content}
+	if manifest.MediaType == "" {
+		manifest.MediaType = detectManifestType(content)
+	}
+	parsed, err := manifest.Parse()
+	if err != nil {
+		return err
+	}
+	if parsed.IsIndex() {
+		for _, m := range parsed.Manifests {
+			err = rc.PushImage(layout, m, repo, "")
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		blobs := parsed.Layers
+		if parsed.Config != nil {
+			blobs = append([]Descriptor{*parsed.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			err = rc.pushBlob(layout, blob, repo)
+			if err != nil {
+				return fmt.Errorf("could not upload %s: %v", blob.Digest, err)
+			}
+		}
+	}
+	reference := tag
+	if reference == "" {
+		reference = manifest.Digest
+	}
+	digest, err := rc.PutManifest(repo, reference, manifest.MediaType, manifest.Content)
+	if err != nil {
+		return err
+	}
+	if digest != manifest.Digest {
+		return fmt.Errorf("registry stored %s as %s instead of %s", reference, digest, manifest.Digest)
+	}
+	return nil
+}
+
+func (rc *RegistryClient) pushBlob(layout *ImageLayout, blob Descriptor, repo string) error {
+	f, err := layout.OpenBlob(blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	uploaded, err := rc.UploadBlob(repo, blob, f)
+	if err != nil {
+		return err
+	}
+	if uploaded {
+		log.Infof("Uploaded %s", blob.Digest)
+	} else {
+		log.Infof("Skipped %s, it already exists", blob.Digest)
+	}
+	return nil
+}
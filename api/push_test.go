@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Push", func() {
+	var source, target *fakeRegistry
+	var dir string
+	var layout *api.ImageLayout
+
+	BeforeEach(func() {
+		source = newFakeRegistry("")
+		target = newFakeRegistry("secret")
+		dir, _ = ioutil.TempDir("", "layout")
+		amd := pushImage(source, "user/repo", "", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "base", "a big amd64 layer")
+		arm := pushImage(source, "user/repo", "", api.ImageConfig{Architecture: "arm64", OS: "linux"}, "base", "arm-layer")
+		pushIndex(source, "user/repo", "1.0", map[string]string{"linux/amd64": amd, "linux/arm64": arm})
+		layout, _ = api.NewImageLayout(filepath.Join(dir, "layout"))
+		_, err := api.NewRegistryClient(source.server.URL, "", "").SaveImage("user/repo", "1.0", nil, layout, "1.0")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		source.server.Close()
+		target.server.Close()
+		_ = os.RemoveAll(dir)
+	})
+
+	It("should push layouts and skip existing blobs", func() {
+		target.addBlob([]byte("base"))
+		client := api.NewRegistryClient(target.server.URL, "", "")
+		client.ChunkSize = 8
+		desc, err := layout.FindManifest("1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.PushImage(layout, *desc, "other/app", "2.0")).To(Succeed())
+		//Two configs and two layers, the base layer already existed
+		Expect(target.uploaded).To(Equal(4))
+		pushed, err := client.GetManifest("other/app", "2.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pushed.Digest).To(Equal(desc.Digest))
+		Expect(pushed.MediaType).To(Equal(api.MediaTypeOCIIndex))
+		amd64, _ := api.ParsePlatform("linux/amd64")
+		image, err := client.GetImage("other/app", "2.0", amd64)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := client.GetBlob("other/app", image.Manifest.Layers[1].Digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("a big amd64 layer"))
+	})
+
+	It("should push a single manifest layout to another tag", func() {
+		desc, err := layout.SelectManifest("", "2.0")
+		Expect(err).NotTo(HaveOccurred())
+		client := api.NewRegistryClient(target.server.URL, "", "")
+		Expect(client.PushImage(layout, *desc, "user/repo", "2.0")).To(Succeed())
+		pushed, err := client.GetManifest("user/repo", "2.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pushed.Digest).To(Equal(desc.Digest))
+		_, err = layout.SelectManifest("missing", "2.0")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should push layout tarballs", func() {
+		tarPath := filepath.Join(dir, "image.tar")
+		f, _ := os.Create(tarPath)
+		Expect(layout.WriteTar(f)).To(Succeed())
+		f.Close()
+		extracted, err := api.ExtractLayoutTar(tarPath, filepath.Join(dir, "extracted"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = extracted.FindManifest("missing")
+		Expect(err).To(HaveOccurred())
+		desc, err := extracted.FindManifest("")
+		Expect(err).NotTo(HaveOccurred())
+		client := api.NewRegistryClient(target.server.URL, "", "")
+		Expect(client.PushImage(extracted, *desc, "other/app", "latest")).To(Succeed())
+		Expect(target.uploaded).To(Equal(5))
+	})
+})
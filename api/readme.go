@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+//The longest descriptions that the hub accepts
+const (
+	MaxDescriptionLength     = 100
+	MaxFullDescriptionLength = 25000
+)
+
+var markdownImageRx = regexp.MustCompile(`!\[([^\]]*)\]\(\s*([^)\s]+)`)
+var markdownLinkRx = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\]]*\])*)\]\(\s*([^)\s]+)`)
+var markdownReferenceRx = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:\s*)(\S+)`)
+var htmlAttributeRx = regexp.MustCompile(`(?i)\b(src|href)(\s*=\s*["'])([^"']+)`)
+
+//ValidateDescriptions checks that the short and full descriptions of a repository fit in the hub's limits.
+func ValidateDescriptions(short, full string) error {
+	if length := utf8.RuneCountInString(short); length > MaxDescriptionLength {
+		return fmt.Errorf("the short description has %d characters, the limit is %d", length, MaxDescriptionLength)
+	}
+	if length := utf8.RuneCountInString(full); length > MaxFullDescriptionLength {
+		return fmt.Errorf("the full description has %d characters, the limit is %d", length, MaxFullDescriptionLength)
+	}
+	return nil
+}
+
+//GitSource is the directory of a git repository that a README comes from, used to resolve its relative links.
+type GitSource struct {
+	//github.com, bitbucket.org or gitlab.com
+	Host  string
+	Owner string
+	Repo  string
+	//The branch, tag or commit
+	Ref string
+	//The directory of the README inside the git repository, empty for the root
+	Dir string
+}
+
+//ParseGitSource parses a git repository like github.com/owner/repo, https://github.com/owner/repo.git or git@github.com:owner/repo.git
+func ParseGitSource(repository, ref, dir string) (*GitSource, error) {
+	str := strings.TrimSuffix(strings.TrimSuffix(repository, "/"), ".git")
+	if strings.HasPrefix(str, "git@") {
+		str = strings.Replace(strings.TrimPrefix(str, "git@"), ":", "/", 1)
+	} else if ix := strings.Index(str, "://"); ix >= 0 {
+		str = str[ix+3:]
+	}
+	parts := strings.Split(str, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid git repository %s, use a repository like github.com/owner/repo", repository)
+	}
+	host := strings.TrimPrefix(strings.ToLower(parts[0]), "www.")
+	if host != "github.com" && host != "bitbucket.org" && host != "gitlab.com" {
+		return nil, fmt.Errorf("links to %s are not supported, only github.com, bitbucket.org and gitlab.com", host)
+	}
+	if ref == "" {
+		ref = "master"
+	}
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	return &GitSource{Host: host, Owner: parts[1], Repo: parts[2], Ref: ref, Dir: dir}, nil
+}
+
+//fileUrl gets the url of a file in the git repository, the raw contents of the file if raw is set or the page that shows it
+func (s *GitSource) fileUrl(file string, raw bool) string {
+	switch {
+	case s.Host == "github.com" && raw:
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", s.Owner, s.Repo, s.Ref, file)
+	case s.Host == "github.com":
+		return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", s.Owner, s.Repo, s.Ref, file)
+	case s.Host == "bitbucket.org" && raw:
+		return fmt.Sprintf("https://bitbucket.org/%s/%s/raw/%s/%s", s.Owner, s.Repo, s.Ref, file)
+	case s.Host == "bitbucket.org":
+		return fmt.Sprintf("https://bitbucket.org/%s/%s/src/%s/%s", s.Owner, s.Repo, s.Ref, file)
+	case raw:
+		return fmt.Sprintf("https://%s/%s/%s/-/raw/%s/%s", s.Host, s.Owner, s.Repo, s.Ref, file)
+	}
+	return fmt.Sprintf("https://%s/%s/%s/-/blob/%s/%s", s.Host, s.Owner, s.Repo, s.Ref, file)
+}
+
+//AbsoluteLinks rewrites the relative links and images of a markdown text to point to the files in the git repository.
+//Images point to the raw files so that they can be shown, links point to the pages of the files.
+func (s *GitSource) AbsoluteLinks(markdown string) string {
+	return rewriteLinks(markdown, func(target string, image bool) string {
+		if !isRelativeLink(target) {
+			return target
+		}
+		file, suffix := target, ""
+		if ix := strings.IndexAny(file, "?#"); ix >= 0 {
+			file, suffix = file[:ix], file[ix:]
+		}
+		if strings.HasPrefix(file, "/") {
+			file = path.Clean(file)
+		} else {
+			file = path.Clean("/" + path.Join(s.Dir, file))
+		}
+		return s.fileUrl(strings.TrimPrefix(file, "/"), image) + suffix
+	})
+}
+
+//RelativeLinks is the reverse of AbsoluteLinks, it turns the links to files in the git repository back into relative ones.
+func (s *GitSource) RelativeLinks(markdown string) string {
+	return rewriteLinks(markdown, func(target string, image bool) string {
+		prefix := s.fileUrl("", image)
+		if !strings.HasPrefix(target, prefix) {
+			return target
+		}
+		file := strings.TrimPrefix(target, prefix)
+		if s.Dir == "" {
+			return file
+		}
+		if strings.HasPrefix(file, s.Dir+"/") {
+			return strings.TrimPrefix(file, s.Dir+"/")
+		}
+		return "/" + file
+	})
+}
+
+//FindRelativeLinks gets the relative links and images of a markdown text.
+func FindRelativeLinks(markdown string) []string {
+	var links []string
+	rewriteLinks(markdown, func(target string, image bool) string {
+		if isRelativeLink(target) {
+			links = append(links, target)
+		}
+		return target
+	})
+	return links
+}
+
+//isRelativeLink checks if a link points to a file next to the document, anchors and links with a scheme or host are not relative
+func isRelativeLink(target string) bool {
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "//") {
+		return false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" && u.Host == ""
+}
+
+//rewriteLinks replaces the targets of the markdown links, images, link references and html src and href attributes.
+//Fenced code blocks are left as they are.
+func rewriteLinks(markdown string, rewrite func(target string, image bool) string) string {
+	var sb strings.Builder
+	var text []string
+	inCode := false
+	flush := func() {
+		if len(text) > 0 {
+			sb.WriteString(rewriteTextLinks(strings.Join(text, ""), rewrite))
+			text = nil
+		}
+	}
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inCode = !inCode
+			sb.WriteString(line)
+			continue
+		}
+		if inCode {
+			sb.WriteString(line)
+		} else {
+			text = append(text, line)
+		}
+	}
+	flush()
+	return sb.String()
+}
+
+func rewriteTextLinks(text string, rewrite func(target string, image bool) string) string {
+	replace := func(rx *regexp.Regexp, text string, image func(match []string) bool) string {
+		return rx.ReplaceAllStringFunc(text, func(m string) string {
+			match := rx.FindStringSubmatch(m)
+			target := match[len(match)-1]
+			return strings.TrimSuffix(m, target) + rewrite(target, image(match))
+		})
+	}
+	//Images go first, so that the images inside links are already rewritten once the links are
+	text = replace(markdownImageRx, text, func(match []string) bool { return true })
+	text = replace(markdownLinkRx, text, func(match []string) bool { return false })
+	text = replace(markdownReferenceRx, text, func(match []string) bool { return false })
+	text = replace(htmlAttributeRx, text, func(match []string) bool { return strings.ToLower(match[1]) == "src" })
+	return text
+}
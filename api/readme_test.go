@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Readme", func() {
+	readme := strings.Join([]string{
+		"# App",
+		"[![Build](docs/badge.svg)](docs/BUILD.md)",
+		"See [the docs](docs/usage.md#install) or [the site](https://example.com) or [below](#usage).",
+		"<img src=\"./logo.png\" width=\"100\">",
+		"[license]: /LICENSE",
+		"```",
+		"[not a link](docs/code.md)",
+		"```",
+		"",
+	}, "\n")
+
+	It("should rewrite relative links to the git repository", func() {
+		src, err := api.ParseGitSource("https://github.com/owner/app.git", "main", "")
+		Expect(err).NotTo(HaveOccurred())
+		output := src.AbsoluteLinks(readme)
+		Expect(output).To(ContainSubstring("[![Build](https://raw.githubusercontent.com/owner/app/main/docs/badge.svg)](https://github.com/owner/app/blob/main/docs/BUILD.md)"))
+		Expect(output).To(ContainSubstring("[the docs](https://github.com/owner/app/blob/main/docs/usage.md#install)"))
+		Expect(output).To(ContainSubstring("[the site](https://example.com)"))
+		Expect(output).To(ContainSubstring("[below](#usage)"))
+		Expect(output).To(ContainSubstring(`<img src="https://raw.githubusercontent.com/owner/app/main/logo.png"`))
+		Expect(output).To(ContainSubstring("[license]: https://github.com/owner/app/blob/main/LICENSE"))
+		Expect(output).To(ContainSubstring("[not a link](docs/code.md)"))
+		Expect(api.FindRelativeLinks(output)).To(BeEmpty())
+		Expect(api.FindRelativeLinks(readme)).To(Equal([]string{"docs/badge.svg", "docs/BUILD.md", "docs/usage.md#install", "/LICENSE", "./logo.png"}))
+	})
+
+	It("should resolve links from a subdirectory and turn them back", func() {
+		src, err := api.ParseGitSource("git@bitbucket.org:owner/app.git", "", "docker/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src.Ref).To(Equal("master"))
+		output := src.AbsoluteLinks("[a](../README.md) [b](run.sh) ![c](/img/c.png)")
+		Expect(output).To(Equal("[a](https://bitbucket.org/owner/app/src/master/README.md) " +
+			"[b](https://bitbucket.org/owner/app/src/master/docker/run.sh) " +
+			"![c](https://bitbucket.org/owner/app/raw/master/img/c.png)"))
+		Expect(src.RelativeLinks(output)).To(Equal("[a](/README.md) [b](run.sh) ![c](/img/c.png)"))
+	})
+
+	It("should reject unknown git hosts", func() {
+		_, err := api.ParseGitSource("example.com/owner/app", "", "")
+		Expect(err).To(HaveOccurred())
+		_, err = api.ParseGitSource("github.com/owner", "", "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should validate the description lengths", func() {
+		Expect(api.ValidateDescriptions(strings.Repeat("a", 100), strings.Repeat("é", 25000))).To(Succeed())
+		Expect(api.ValidateDescriptions(strings.Repeat("a", 101), "")).NotTo(Succeed())
+		Expect(api.ValidateDescriptions("", strings.Repeat("a", 25001))).NotTo(Succeed())
+	})
+
+	It("should create unified diffs", func() {
+		from := "a\nb\nc\nd\ne\nf\ng\nh\n"
+		to := "a\nB\nc\nd\ne\nf\ng\nh\ni\n"
+		Expect(api.UnifiedDiff("remote", "README.md", from, from, 3)).To(BeEmpty())
+		Expect(api.UnifiedDiff("remote", "README.md", from, to, 1)).To(Equal(strings.Join([]string{
+			"--- remote",
+			"+++ README.md",
+			"@@ -1,3 +1,3 @@",
+			" a",
+			"-b",
+			"+B",
+			" c",
+			"@@ -8,1 +8,2 @@",
+			" h",
+			"+i",
+			"",
+		}, "\n")))
+		Expect(api.UnifiedDiff("a", "b", "", "x\n", 3)).To(Equal("--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n"))
+	})
+})
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var tagRx = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+var digestRx = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+//ImageReference points to an image in a repository, either by tag, by digest or both.
+type ImageReference struct {
+	//The registry host, empty for docker hub
+	Registry  string
+	Namespace string
+	//The repository name, this may contain slashes for registries other than docker hub
+	Name   string
+	Tag    string
+	Digest string
+}
+
+//ParseReference parses image references like `repo`, `user/repo:tag`, `user/repo@sha256:...` or `ghcr.io/owner/repo:tag`.
+//Official docker hub images (no namespace or `_`) are resolved to the `library` namespace,
+//single component names on other registries are kept without a namespace.
+func ParseReference(ref string) (*ImageReference, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("no image reference given")
+	}
+	r := &ImageReference{}
+	remainder := ref
+	if ix := strings.Index(remainder, "@"); ix >= 0 {
+		r.Digest = remainder[ix+1:]
+		remainder = remainder[:ix]
+		if !IsDigest(r.Digest) {
+			return nil, fmt.Errorf("invalid digest in %s", ref)
+		}
+	}
+	if ix := strings.LastIndex(remainder, ":"); ix >= 0 && !strings.Contains(remainder[ix+1:], "/") {
+		r.Tag = remainder[ix+1:]
+		remainder = remainder[:ix]
+		if !tagRx.MatchString(r.Tag) {
+			return nil, fmt.Errorf("invalid tag in %s", ref)
+		}
+	}
+	parts := strings.Split(remainder, "/")
+	if len(parts) > 1 && isRegistryHost(parts[0]) {
+		r.Registry = parts[0]
+		parts = parts[1:]
+		if r.Registry == "docker.io" || r.Registry == "index.docker.io" {
+			r.Registry = ""
+		}
+	}
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid image reference %s", ref)
+		}
+	}
+	if r.Registry == "" && len(parts) > 2 {
+		return nil, fmt.Errorf("invalid docker hub repository %s", ref)
+	}
+	if len(parts) == 1 {
+		if r.Registry != "" {
+			r.Name = strings.ToLower(parts[0])
+			return r, nil
+		}
+		parts = []string{"library", parts[0]}
+	}
+	r.Namespace = strings.ToLower(parts[0])
+	if r.Namespace == "_" {
+		r.Namespace = "library"
+	}
+	r.Name = strings.ToLower(strings.Join(parts[1:], "/"))
+	return r, nil
+}
+
+//IsDigest checks if the given string is a sha256 content digest.
+func IsDigest(str string) bool {
+	return digestRx.MatchString(str)
+}
+
+func isRegistryHost(part string) bool {
+	return strings.ContainsAny(part, ".:") || part == "localhost"
+}
+
+//Repository gets the repository path, without a registry host, tag or digest.
+func (r *ImageReference) Repository() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s/%s", r.Namespace, r.Name)
+}
+
+//Reference gets the digest if one is given, otherwise the tag, `latest` being the default.
+func (r *ImageReference) Reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return "latest"
+}
+
+//WithTag creates a copy of the reference that points to another tag in the same repository.
+func (r *ImageReference) WithTag(tag string) *ImageReference {
+	return &ImageReference{Registry: r.Registry, Namespace: r.Namespace, Name: r.Name, Tag: tag}
+}
+
+//WithDigest creates a copy of the reference that points to a digest in the same repository.
+func (r *ImageReference) WithDigest(digest string) *ImageReference {
+	return &ImageReference{Registry: r.Registry, Namespace: r.Namespace, Name: r.Name, Digest: digest}
+}
+
+func (r *ImageReference) String() string {
+	name := r.Repository()
+	if r.Registry != "" {
+		name = r.Registry + "/" + name
+	}
+	if r.Tag != "" {
+		name += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		name += "@" + r.Digest
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Reference", func() {
+	digest := "sha256:" + "ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12ab12"
+
+	It("should default to the library namespace", func() {
+		ref, err := api.ParseReference("nginx")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Repository()).To(Equal("library/nginx"))
+		Expect(ref.Reference()).To(Equal("latest"))
+	})
+
+	It("should parse tags and digests", func() {
+		ref, err := api.ParseReference("User/repo:1.0@" + digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Repository()).To(Equal("user/repo"))
+		Expect(ref.Tag).To(Equal("1.0"))
+		Expect(ref.Reference()).To(Equal(digest))
+		Expect(ref.String()).To(Equal("user/repo:1.0@" + digest))
+	})
+
+	It("should parse registry hosts", func() {
+		ref, err := api.ParseReference("localhost:5000/team/app/web:dev")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Registry).To(Equal("localhost:5000"))
+		Expect(ref.Repository()).To(Equal("team/app/web"))
+		Expect(ref.Tag).To(Equal("dev"))
+		ref, err = api.ParseReference("docker.io/alpine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Repository()).To(Equal("library/alpine"))
+		ref, err = api.ParseReference("docker.io/library/alpine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Registry).To(BeEmpty())
+	})
+
+	It("should only default to the library namespace on docker hub", func() {
+		ref, err := api.ParseReference("localhost:5000/myimage:1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Repository()).To(Equal("myimage"))
+		Expect(ref.String()).To(Equal("localhost:5000/myimage:1"))
+		ref, err = api.ParseReference("ghcr.io/foo:1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Registry).To(Equal("ghcr.io"))
+		Expect(ref.Repository()).To(Equal("foo"))
+		Expect(ref.String()).To(Equal("ghcr.io/foo:1"))
+	})
+
+	It("should reject invalid references", func() {
+		for _, r := range []string{"", "user/repo@sha256:123", "a/b/c", "user//repo"} {
+			_, err := api.ParseReference(r)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
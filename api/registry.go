@@ -0,0 +1,319 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var challengeParamRx = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+//RegistryClient talks to a docker registry through the registry v2 api, for things the hub api doesn't offer.
+type RegistryClient struct {
+	client   *http.Client
+	base     string
+	username string
+	password string
+	//Authorization headers for each scope
+	tokens map[string]string
+	lock   sync.Mutex
+	//Blobs bigger than this are uploaded in chunks
+	ChunkSize int64
+}
+
+//NewRegistryClient creates a client for the given registry host.
+//The host may contain a scheme, https is used if it doesn't. Credentials are optional.
+func NewRegistryClient(host, username, password string) *RegistryClient {
+	base := host
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		base = "https://" + host
+	}
+	transport := &http.Transport{
+		Proxy:              http.ProxyFromEnvironment,
+		DisableCompression: false,
+	}
+	return &RegistryClient{
+		//No timeout is set since blobs can take a while to transfer.
+		client:    &http.Client{Transport: transport},
+		base:      strings.TrimRight(base, "/"),
+		username:  username,
+		password:  password,
+		tokens:    make(map[string]string),
+		ChunkSize: DefaultChunkSize,
+	}
+}
+
+func pullScope(repo string) string {
+	return fmt.Sprintf("repository:%s:pull", repo)
+}
+
+func pushScope(repo string) string {
+	return fmt.Sprintf("repository:%s:pull,push", repo)
+}
+
+func deleteScope(repo string) string {
+	return fmt.Sprintf("repository:%s:*", repo)
+}
+
+func (rc *RegistryClient) getRoute(repo string, p ...string) string {
+	return joinURL(rc.base, append([]string{"v2", repo}, p...)...)
+}
+
+func (rc *RegistryClient) authorize(req *http.Request, scope string) {
+	rc.lock.Lock()
+	auth := rc.tokens[scope]
+	rc.lock.Unlock()
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+}
+
+//authenticate handles an authentication challenge from the registry, getting a token for the given scope if needed.
+func (rc *RegistryClient) authenticate(challenge, scope string) error {
+	var auth string
+	lowerChallenge := strings.ToLower(challenge)
+	switch {
+	case strings.HasPrefix(lowerChallenge, "basic"):
+		if rc.username == "" {
+			return &RegistryError{StatusCode: http.StatusUnauthorized, Message: "login required"}
+		}
+		req, _ := http.NewRequest("GET", rc.base, nil)
+		req.SetBasicAuth(rc.username, rc.password)
+		auth = req.Header.Get("Authorization")
+	case strings.HasPrefix(lowerChallenge, "bearer"):
+		params := make(map[string]string)
+		for _, m := range challengeParamRx.FindAllStringSubmatch(challenge, -1) {
+			params[strings.ToLower(m[1])] = m[2]
+		}
+		if params["realm"] == "" {
+			return fmt.Errorf("invalid authentication challenge: %s", challenge)
+		}
+		token, err := rc.fetchToken(params["realm"], params["service"], scope)
+		if err != nil {
+			return err
+		}
+		auth = "Bearer " + token
+	default:
+		return &RegistryError{StatusCode: http.StatusUnauthorized, Message: "unsupported authentication: " + challenge}
+	}
+	rc.lock.Lock()
+	rc.tokens[scope] = auth
+	rc.lock.Unlock()
+	return nil
+}
+
+func (rc *RegistryClient) fetchToken(realm, service, scope string) (string, error) {
+	query := url.Values{}
+	if service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	req, err := http.NewRequest("GET", realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if rc.username != "" {
+		req.SetBasicAuth(rc.username, rc.password)
+	}
+	res, err := rc.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode >= 400 {
+		return "", &RegistryError{StatusCode: res.StatusCode, Message: "could not authenticate: " + parseRegistryError(body)}
+	}
+	var tokenRes struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.Unmarshal(body, &tokenRes)
+	if err != nil {
+		return "", err
+	}
+	if tokenRes.Token != "" {
+		return tokenRes.Token, nil
+	}
+	return tokenRes.AccessToken, nil
+}
+
+//do sends a request, authenticating for the given scope when the registry asks for it.
+//Error statuses are turned into a RegistryError and the response is closed.
+func (rc *RegistryClient) do(req *http.Request, scope string) (*http.Response, error) {
+	rc.authorize(req, scope)
+	res, err := rc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		err = rc.authenticate(challenge, scope)
+		if err != nil {
+			return nil, err
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("could not resend request to %s after authenticating", req.URL)
+			}
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+		req.Header.Del("Authorization")
+		rc.authorize(req, scope)
+		res, err = rc.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		message := parseRegistryError(body)
+		if message == "" {
+			message = http.StatusText(res.StatusCode)
+		}
+		if rc.username == "" && (res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden) {
+			message = "login required, " + message
+		}
+		return nil, &RegistryError{StatusCode: res.StatusCode, Message: message}
+	}
+	return res, nil
+}
+
+//GetManifest gets the manifest for a tag or a digest, exactly as it's stored in the registry.
+func (rc *RegistryClient) GetManifest(repo, reference string) (*RawManifest, error) {
+	req, err := http.NewRequest("GET", rc.getRoute(repo, "manifests", reference), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	res, err := rc.do(req, pullScope(repo))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &RawManifest{
+		MediaType: res.Header.Get("Content-Type"),
+		Digest:    digestOf(content),
+		Content:   content,
+	}
+	if IsDigest(reference) && manifest.Digest != reference {
+		return nil, fmt.Errorf("manifest digest mismatch, expected %s but got %s", reference, manifest.Digest)
+	}
+	if manifest.MediaType == "" || manifest.MediaType == "application/json" {
+		manifest.MediaType = detectManifestType(content)
+	}
+	return manifest, nil
+}
+
+//HeadManifest gets a descriptor for a tag or a digest without downloading the manifest.
+func (rc *RegistryClient) HeadManifest(repo, reference string) (*Descriptor, error) {
+	req, err := http.NewRequest("HEAD", rc.getRoute(repo, "manifests", reference), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	res, err := rc.do(req, pullScope(repo))
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		//Some registries only send the digest on GET requests.
+		manifest, err := rc.GetManifest(repo, reference)
+		if err != nil {
+			return nil, err
+		}
+		desc := manifest.Descriptor()
+		return &desc, nil
+	}
+	return &Descriptor{
+		MediaType: res.Header.Get("Content-Type"),
+		Digest:    digest,
+		Size:      res.ContentLength,
+	}, nil
+}
+
+//PutManifest uploads a manifest under the given tag or digest, returning the digest the registry stored it with.
+func (rc *RegistryClient) PutManifest(repo, reference, mediaType string, content []byte) (string, error) {
+	req, err := http.NewRequest("PUT", rc.getRoute(repo, "manifests", reference), bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	res, err := rc.do(req, pushScope(repo))
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = digestOf(content)
+	}
+	return digest, nil
+}
+
+//TagManifest points new tags to an existing manifest in the same repository.
+//The manifest is pushed back with its original media type and bytes, so its digest stays the same.
+func (rc *RegistryClient) TagManifest(repo, reference string, tags ...string) (*RawManifest, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	for _, tag := range tags {
+		if !tagRx.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %s", tag)
+		}
+	}
+	manifest, err := rc.GetManifest(repo, reference)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		digest, err := rc.PutManifest(repo, tag, manifest.MediaType, manifest.Content)
+		if err != nil {
+			return nil, fmt.Errorf("could not tag %s:%s: %v", repo, tag, err)
+		}
+		if digest != manifest.Digest {
+			return nil, fmt.Errorf("registry stored %s:%s as %s instead of %s", repo, tag, digest, manifest.Digest)
+		}
+	}
+	return manifest, nil
+}
+
+//DeleteManifest deletes a manifest by digest, which also removes the tags that point to it.
+//Registries may refuse this, docker hub only supports deleting through its own api.
+func (rc *RegistryClient) DeleteManifest(repo, digest string) error {
+	if !IsDigest(digest) {
+		return fmt.Errorf("manifests can only be deleted by digest, got %s", digest)
+	}
+	req, err := http.NewRequest("DELETE", rc.getRoute(repo, "manifests", digest), nil)
+	if err != nil {
+		return err
+	}
+	res, err := rc.do(req, deleteScope(repo))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
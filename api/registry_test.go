@@ -0,0 +1,279 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+type storedManifest struct {
+	mediaType string
+	content   []byte
+}
+
+//fakeRegistry is an in-memory registry that implements enough of the v2 api for the tests.
+type fakeRegistry struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	manifests map[string]storedManifest
+	tags      map[string]string
+	blobs     map[string][]byte
+	uploads   map[string][]byte
+	//The number of blobs that were uploaded
+	uploaded int
+	token    string
+	//Set to answer referrers requests with this status, like registries without the referrers api
+	referrersStatus int
+}
+
+func newFakeRegistry(token string) *fakeRegistry {
+	fr := &fakeRegistry{
+		manifests: make(map[string]storedManifest),
+		tags:      make(map[string]string),
+		blobs:     make(map[string][]byte),
+		uploads:   make(map[string][]byte),
+		token:     token,
+	}
+	fr.server = httptest.NewServer(http.HandlerFunc(fr.handle))
+	return fr
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (fr *fakeRegistry) addManifest(repo, tag, mediaType string, content []byte) string {
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	digest := digestOf(content)
+	fr.manifests[repo+"@"+digest] = storedManifest{mediaType, content}
+	if tag != "" {
+		fr.tags[repo+":"+tag] = digest
+	}
+	return digest
+}
+
+func (fr *fakeRegistry) addBlob(content []byte) string {
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	digest := digestOf(content)
+	fr.blobs[digest] = content
+	return digest
+}
+
+func (fr *fakeRegistry) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		_, _ = fmt.Fprintf(w, `{"token": "%s"}`, fr.token)
+		return
+	}
+	if fr.token != "" && r.Header.Get("Authorization") != "Bearer "+fr.token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, fr.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	pth := strings.TrimPrefix(r.URL.Path, "/v2/")
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	switch {
+	case strings.Contains(pth, "/blobs/uploads/"):
+		parts := strings.SplitN(pth, "/blobs/uploads/", 2)
+		fr.handleUpload(w, r, parts[0], parts[1])
+	case strings.Contains(pth, "/referrers/"):
+		parts := strings.SplitN(pth, "/referrers/", 2)
+		fr.handleReferrers(w, parts[0], parts[1])
+	case strings.Contains(pth, "/manifests/"):
+		parts := strings.SplitN(pth, "/manifests/", 2)
+		fr.handleManifest(w, r, parts[0], parts[1])
+	case strings.Contains(pth, "/blobs/"):
+		parts := strings.SplitN(pth, "/blobs/", 2)
+		fr.handleBlob(w, r, parts[0], parts[1])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (fr *fakeRegistry) handleManifest(w http.ResponseWriter, r *http.Request, repo, reference string) {
+	digest := reference
+	if !api.IsDigest(reference) {
+		digest = fr.tags[repo+":"+reference]
+	}
+	switch r.Method {
+	case "GET", "HEAD":
+		m, ok := fr.manifests[repo+"@"+digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", fmt.Sprint(len(m.content)))
+		if r.Method == "GET" {
+			_, _ = w.Write(m.content)
+		}
+	case "PUT":
+		content, _ := ioutil.ReadAll(r.Body)
+		digest = digestOf(content)
+		fr.manifests[repo+"@"+digest] = storedManifest{r.Header.Get("Content-Type"), content}
+		if !api.IsDigest(reference) {
+			fr.tags[repo+":"+reference] = digest
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if _, ok := fr.manifests[repo+"@"+digest]; !ok || !api.IsDigest(reference) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(fr.manifests, repo+"@"+digest)
+		for tag, d := range fr.tags {
+			if d == digest && strings.HasPrefix(tag, repo+":") {
+				delete(fr.tags, tag)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (fr *fakeRegistry) handleBlob(w http.ResponseWriter, r *http.Request, repo, digest string) {
+	content, ok := fr.blobs[digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+var _ = Describe("Registry", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{},"layers":[]}`)
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("secret")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should authenticate and get manifests", func() {
+		digest := registry.addManifest("user/repo", "latest", api.MediaTypeDockerManifest, manifest)
+		m, err := client.GetManifest("user/repo", "latest")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Digest).To(Equal(digest))
+		Expect(m.MediaType).To(Equal(api.MediaTypeDockerManifest))
+		Expect(m.Content).To(Equal(manifest))
+	})
+
+	It("should report missing manifests", func() {
+		_, err := client.GetManifest("user/repo", "missing")
+		Expect(api.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should add tags without changing the digest", func() {
+		digest := registry.addManifest("user/repo", "", api.MediaTypeDockerManifest, manifest)
+		m, err := client.TagManifest("user/repo", digest, "stable", "1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Digest).To(Equal(digest))
+		for _, tag := range []string{"stable", "1.0"} {
+			desc, err := client.HeadManifest("user/repo", tag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desc.Digest).To(Equal(digest))
+			Expect(desc.MediaType).To(Equal(api.MediaTypeDockerManifest))
+		}
+	})
+
+	It("should delete manifests by digest", func() {
+		digest := registry.addManifest("user/repo", "latest", api.MediaTypeDockerManifest, manifest)
+		Expect(client.DeleteManifest("user/repo", "latest")).NotTo(Succeed())
+		Expect(client.DeleteManifest("user/repo", digest)).To(Succeed())
+		_, err := client.GetManifest("user/repo", "latest")
+		Expect(api.IsNotFound(err)).To(BeTrue())
+		Expect(api.IsNotFound(client.DeleteManifest("user/repo", digest))).To(BeTrue())
+	})
+
+	It("should ask for a login when anonymous access is refused", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		_, err := api.NewRegistryClient(server.URL, "", "").GetManifest("user/repo", "latest")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("login required"))
+	})
+})
+
+func (fr *fakeRegistry) handleUpload(w http.ResponseWriter, r *http.Request, repo, id string) {
+	switch r.Method {
+	case "POST":
+		id = fmt.Sprint(len(fr.uploads) + 1)
+		fr.uploads[id] = []byte{}
+	case "PATCH":
+		content, _ := ioutil.ReadAll(r.Body)
+		expected := fmt.Sprintf("%d-%d", len(fr.uploads[id]), len(fr.uploads[id])+len(content)-1)
+		if r.Header.Get("Content-Range") != expected {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		fr.uploads[id] = append(fr.uploads[id], content...)
+	case "PUT":
+		content, _ := ioutil.ReadAll(r.Body)
+		content = append(fr.uploads[id], content...)
+		digest := r.URL.Query().Get("digest")
+		if digestOf(content) != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"DIGEST_INVALID","message":"digest mismatch"}]}`))
+			return
+		}
+		fr.blobs[digest] = content
+		fr.uploaded++
+		delete(fr.uploads, id)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, id))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (fr *fakeRegistry) handleReferrers(w http.ResponseWriter, repo, digest string) {
+	if fr.referrersStatus != 0 {
+		w.WriteHeader(fr.referrersStatus)
+		return
+	}
+	index := api.Manifest{SchemaVersion: 2, MediaType: api.MediaTypeOCIIndex, Manifests: []api.Descriptor{}}
+	for key, m := range fr.manifests {
+		var parsed api.Manifest
+		_ = json.Unmarshal(m.content, &parsed)
+		if strings.HasPrefix(key, repo+"@") && parsed.Subject != nil && parsed.Subject.Digest == digest {
+			index.Manifests = append(index.Manifests, api.Descriptor{
+				MediaType:    m.mediaType,
+				Digest:       strings.TrimPrefix(key, repo+"@"),
+				Size:         int64(len(m.content)),
+				ArtifactType: parsed.ArtifactType,
+				Annotations:  parsed.Annotations,
+			})
+		}
+	}
+	w.Header().Set("Content-Type", api.MediaTypeOCIIndex)
+	_ = json.NewEncoder(w).Encode(index)
+}
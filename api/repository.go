@@ -7,13 +7,63 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/sp0x/docker-hub-cli/requests"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+//UserRepository is the summary of a repository that repository listings return.
 type UserRepository struct {
-	Namespace string `json:"namespace"`
-	Name      string `json:"name"`
+	Namespace         string     `json:"namespace"`
+	Name              string     `json:"name"`
+	RepositoryType    string     `json:"repository_type"`
+	Status            int        `json:"status"`
+	StatusDescription string     `json:"status_description"`
+	Description       string     `json:"description"`
+	IsPrivate         bool       `json:"is_private"`
+	IsAutomated       bool       `json:"is_automated"`
+	StarCount         int        `json:"star_count"`
+	PullCount         int        `json:"pull_count"`
+	LastUpdated       *time.Time `json:"last_updated"`
+}
+
+//StatusName gets the description of the status, like active, or its number if the hub didn't describe it.
+func (r *UserRepository) StatusName() string {
+	if r.StatusDescription != "" {
+		return r.StatusDescription
+	}
+	return strconv.Itoa(r.Status)
+}
+
+//RepositoryList is a list of repository summaries, as returned by the repository listing api.
+type RepositoryList []UserRepository
+
+//Sort sorts the repositories by name, pulls, stars or updated.
+//Names are sorted alphabetically, the others have the most popular or most recently updated repositories first.
+func (repos RepositoryList) Sort(by string) error {
+	var less func(a, b *UserRepository) bool
+	switch by {
+	case "name":
+		less = func(a, b *UserRepository) bool { return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name }
+	case "pulls":
+		less = func(a, b *UserRepository) bool { return a.PullCount > b.PullCount }
+	case "stars":
+		less = func(a, b *UserRepository) bool { return a.StarCount > b.StarCount }
+	case "updated":
+		less = func(a, b *UserRepository) bool {
+			if a.LastUpdated == nil || b.LastUpdated == nil {
+				return b.LastUpdated == nil && a.LastUpdated != nil
+			}
+			return a.LastUpdated.After(*b.LastUpdated)
+		}
+	default:
+		return fmt.Errorf("can't sort by %s, use name, pulls, stars or updated", by)
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return less(&repos[i], &repos[j])
+	})
+	return nil
 }
 
 type RepositoryPermissions struct {
@@ -32,7 +82,7 @@ type Repository struct {
 	IsPrivate       bool                   `json:"is_private"`
 	IsAutomated     bool                   `json:"is_automated"`
 	CanEdit         bool                   `json:"can_edit"`
-	StarCount       int                    `json:"start_count"`
+	StarCount       int                    `json:"star_count"`
 	PullCount       int                    `json:"pull_count"`
 	LastUpdated     *time.Time             `json:"last_updated"`
 	IsMigrated      bool                   `json:"is_migrated"`
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+//The kinds of settings that can be cloned between repositories
+const (
+	SettingDescription   = "description"
+	SettingPrivacy       = "privacy"
+	SettingCollaborators = "collaborators"
+	SettingWebhooks      = "webhooks"
+	SettingBuilds        = "builds"
+)
+
+var settingKinds = []string{SettingDescription, SettingPrivacy, SettingCollaborators, SettingWebhooks, SettingBuilds}
+
+//RepositorySettings are the settings of a repository that can be cloned to another one.
+type RepositorySettings struct {
+	//The repository with its descriptions and privacy, nil if it doesn't exist
+	Repository    *Repository
+	Collaborators []Collaborator
+	Webhooks      []Webhook
+	//The automated build settings, nil if the repository isn't built automatically
+	Build *BuildSettings
+}
+
+//SettingChange is a change that makes the settings of a repository like the ones of another one.
+type SettingChange struct {
+	Kind    string
+	Summary string
+	apply   func(d *DockerApi, username, name string) error
+}
+
+//Apply makes the change on a repository.
+func (c *SettingChange) Apply(d *DockerApi, username, name string) error {
+	return c.apply(d, username, name)
+}
+
+//ParseSettingKinds parses a comma separated list of setting kinds, all of them are used if the list is empty.
+func ParseSettingKinds(str string) ([]string, error) {
+	if strings.TrimSpace(str) == "" {
+		return settingKinds, nil
+	}
+	var kinds []string
+	for _, kind := range strings.Split(str, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if !containsString(settingKinds, kind) {
+			return nil, fmt.Errorf("unknown setting %s, use %s", kind, strings.Join(settingKinds, ", "))
+		}
+		if !containsString(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//GetRepositorySettings gets the given kinds of settings of a repository.
+//The repository is nil in the settings if it doesn't exist.
+func (d *DockerApi) GetRepositorySettings(username, name string, kinds []string) (*RepositorySettings, error) {
+	settings := &RepositorySettings{}
+	repo, err := d.GetRepository(username, name)
+	if err != nil {
+		if IsNotFound(err) {
+			return settings, nil
+		}
+		return nil, err
+	}
+	settings.Repository = repo
+	if containsString(kinds, SettingCollaborators) {
+		settings.Collaborators, err = d.GetCollaborators(username, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the collaborators: %v", err)
+		}
+	}
+	if containsString(kinds, SettingWebhooks) {
+		settings.Webhooks, err = d.GetAllWebhooks(username, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the webhooks: %v", err)
+		}
+	}
+	if containsString(kinds, SettingBuilds) {
+		settings.Build, err = d.GetBuildSettings(username, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the build settings: %v", err)
+		}
+	}
+	return settings, nil
+}
+
+//PlanSettingsClone plans the changes that give the target the given kinds of settings of the source.
+//Settings are only added, the collaborators, webhooks and build tags that only the target has are kept.
+func PlanSettingsClone(source, target *RepositorySettings, kinds []string) []SettingChange {
+	var changes []SettingChange
+	src, dst := source.Repository, target.Repository
+	if dst == nil {
+		dst = &Repository{}
+	}
+	if containsString(kinds, SettingDescription) && src != nil &&
+		(src.Description != dst.Description || src.FullDescription != dst.FullDescription) {
+		short, full := src.Description, src.FullDescription
+		changes = append(changes, SettingChange{
+			Kind:    SettingDescription,
+			Summary: fmt.Sprintf("set the description to %q and the full description (%d characters)", short, len(full)),
+			apply: func(d *DockerApi, username, name string) error {
+				return d.UpdateRepositoryDescription(username, name, &short, &full)
+			},
+		})
+	}
+	if containsString(kinds, SettingPrivacy) && src != nil && src.IsPrivate != dst.IsPrivate {
+		private := src.IsPrivate
+		visibility := "public"
+		if private {
+			visibility = "private"
+		}
+		changes = append(changes, SettingChange{
+			Kind:    SettingPrivacy,
+			Summary: "make it " + visibility,
+			apply: func(d *DockerApi, username, name string) error {
+				return d.SetRepositoryPrivacy(username, name, private)
+			},
+		})
+	}
+	if containsString(kinds, SettingCollaborators) {
+		existing := make(map[string]bool)
+		for _, c := range target.Collaborators {
+			existing[strings.ToLower(c.User)] = true
+		}
+		for _, c := range source.Collaborators {
+			user := c.User
+			if existing[strings.ToLower(user)] {
+				continue
+			}
+			changes = append(changes, SettingChange{
+				Kind:    SettingCollaborators,
+				Summary: "add collaborator " + user,
+				apply: func(d *DockerApi, username, name string) error {
+					return d.AddCollaborator(username, name, user)
+				},
+			})
+		}
+	}
+	if containsString(kinds, SettingWebhooks) {
+		existing := make(map[string]bool)
+		for _, hook := range target.Webhooks {
+			existing[hook.Name] = true
+		}
+		for _, hook := range source.Webhooks {
+			hookName, urls := hook.Name, hook.GetWebhookUrls()
+			if existing[hookName] || len(urls) == 0 {
+				continue
+			}
+			var calls []string
+			for _, u := range urls {
+				calls = append(calls, u.Url)
+			}
+			changes = append(changes, SettingChange{
+				Kind:    SettingWebhooks,
+				Summary: fmt.Sprintf("add webhook %s calling %s", hookName, strings.Join(calls, ", ")),
+				apply: func(d *DockerApi, username, name string) error {
+					_, err := d.CreateWebhookUrls(username, name, hookName, urls)
+					return err
+				},
+			})
+		}
+	}
+	if containsString(kinds, SettingBuilds) && source.Build != nil {
+		build := source.Build
+		if target.Build == nil {
+			//The build is created with all its tags, and with the privacy that the target will have
+			private := dst.IsPrivate
+			if containsString(kinds, SettingPrivacy) && src != nil {
+				private = src.IsPrivate
+			}
+			var tags []map[string]interface{}
+			var tagSummaries []string
+			for _, tag := range build.BuildTags {
+				tags = append(tags, map[string]interface{}{
+					"name":                tag.Name,
+					"dockerfile_location": tag.DockerfileLocation,
+					"source_type":         tag.SourceType,
+					"source_name":         tag.SourceName,
+				})
+				tagSummaries = append(tagSummaries, buildTagSummary(tag))
+			}
+			summary := fmt.Sprintf("build automatically from %s %s", build.Provider, build.BuildName)
+			if len(tagSummaries) > 0 {
+				summary += ", tagging " + strings.Join(tagSummaries, ", ")
+			}
+			changes = append(changes, SettingChange{
+				Kind:    SettingBuilds,
+				Summary: summary,
+				apply: func(d *DockerApi, username, name string) error {
+					return d.CreateAutomatedBuild(username, name, map[string]interface{}{
+						"provider":      build.Provider,
+						"vcs_repo_name": build.BuildName,
+						"is_private":    private,
+						"build_tags":    tags,
+					})
+				},
+			})
+			return changes
+		}
+		existing := make(map[string]bool)
+		for _, tag := range target.Build.BuildTags {
+			existing[tag.Name] = true
+		}
+		for _, tag := range build.BuildTags {
+			tag := tag
+			if existing[tag.Name] {
+				continue
+			}
+			changes = append(changes, SettingChange{
+				Kind:    SettingBuilds,
+				Summary: "build tag " + buildTagSummary(tag),
+				apply: func(d *DockerApi, username, name string) error {
+					return d.CreateBuildTag(username, name, tag.Name, tag.DockerfileLocation, tag.SourceType, tag.SourceName)
+				},
+			})
+		}
+	}
+	return changes
+}
+
+//buildTagSummary describes which source a build tag is built from
+func buildTagSummary(tag BuildSettingsTag) string {
+	return fmt.Sprintf("%s from %s %s", tag.Name, strings.ToLower(tag.SourceType), tag.SourceName)
+}
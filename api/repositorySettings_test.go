@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("RepositorySettings", func() {
+	hookUrl := "https://ci.example.com/hook"
+	source := &api.RepositorySettings{
+		Repository:    &api.Repository{Namespace: "user", Name: "svc-a", Description: "Service A", FullDescription: "# Service A", IsPrivate: true},
+		Collaborators: []api.Collaborator{{User: "alice"}, {User: "bob"}},
+		Webhooks:      []api.Webhook{{Name: "ci", Hooks: []api.WebhookUrl{{Name: "ci", Url: hookUrl}}}},
+		Build: &api.BuildSettings{Provider: "github", BuildName: "user/svc-a", BuildTags: []api.BuildSettingsTag{
+			{Name: "latest", SourceType: "Branch", SourceName: "master", DockerfileLocation: "/"},
+			{Name: "{sourceref}", SourceType: "Tag", SourceName: "/^v.*$/", DockerfileLocation: "/"},
+		}},
+	}
+	summaries := func(changes []api.SettingChange) []string {
+		var output []string
+		for _, c := range changes {
+			output = append(output, c.Kind+": "+c.Summary)
+		}
+		return output
+	}
+
+	It("should plan all the settings for a new repository", func() {
+		kinds, err := api.ParseSettingKinds("")
+		Expect(err).NotTo(HaveOccurred())
+		changes := api.PlanSettingsClone(source, &api.RepositorySettings{}, kinds)
+		Expect(summaries(changes)).To(Equal([]string{
+			`description: set the description to "Service A" and the full description (11 characters)`,
+			"privacy: make it private",
+			"collaborators: add collaborator alice",
+			"collaborators: add collaborator bob",
+			"webhooks: add webhook ci calling " + hookUrl,
+			"builds: build automatically from github user/svc-a, tagging latest from branch master, {sourceref} from tag /^v.*$/",
+		}))
+	})
+
+	It("should clone all the urls of a webhook and clear descriptions", func() {
+		source := &api.RepositorySettings{
+			Repository: &api.Repository{Namespace: "user", Name: "svc-a"},
+			Webhooks: []api.Webhook{{Name: "ci", Hooks: []api.WebhookUrl{
+				{Name: "ci", Url: hookUrl},
+				{Name: "chat", Url: "https://chat.example.com/hook"},
+			}}},
+		}
+		target := &api.RepositorySettings{
+			Repository: &api.Repository{Namespace: "user", Name: "svc-b", Description: "Old", FullDescription: "# Old"},
+		}
+		kinds, err := api.ParseSettingKinds("description,webhooks")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summaries(api.PlanSettingsClone(source, target, kinds))).To(Equal([]string{
+			`description: set the description to "" and the full description (0 characters)`,
+			"webhooks: add webhook ci calling " + hookUrl + ", https://chat.example.com/hook",
+		}))
+	})
+
+	It("should only add what the target is missing", func() {
+		target := &api.RepositorySettings{
+			Repository:    &api.Repository{Namespace: "user", Name: "svc-b", Description: "Service A", FullDescription: "# Service A"},
+			Collaborators: []api.Collaborator{{User: "Alice"}, {User: "carol"}},
+			Webhooks:      []api.Webhook{{Name: "ci"}},
+			Build:         &api.BuildSettings{Provider: "github", BuildName: "user/svc-b", BuildTags: []api.BuildSettingsTag{{Name: "latest"}}},
+		}
+		kinds, err := api.ParseSettingKinds("collaborators, webhooks,builds")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summaries(api.PlanSettingsClone(source, target, kinds))).To(Equal([]string{
+			"collaborators: add collaborator bob",
+			"builds: build tag {sourceref} from tag /^v.*$/",
+		}))
+	})
+
+	It("should reject unknown settings", func() {
+		_, err := api.ParseSettingKinds("webhooks,tags")
+		Expect(err).To(HaveOccurred())
+	})
+})
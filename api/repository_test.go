@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("RepositoryList", func() {
+	older := time.Now().Add(-48 * time.Hour)
+	newer := time.Now().Add(-time.Hour)
+	names := func(repos api.RepositoryList) []string {
+		var output []string
+		for _, r := range repos {
+			output = append(output, r.Name)
+		}
+		return output
+	}
+	list := func() api.RepositoryList {
+		return api.RepositoryList{
+			{Namespace: "user", Name: "b", PullCount: 10, StarCount: 3, LastUpdated: &older},
+			{Namespace: "user", Name: "c", PullCount: 500, StarCount: 1},
+			{Namespace: "user", Name: "a", PullCount: 50, StarCount: 2, LastUpdated: &newer, IsPrivate: true},
+		}
+	}
+
+	It("should sort by name, pulls, stars and updated", func() {
+		repos := list()
+		Expect(repos.Sort("name")).To(Succeed())
+		Expect(names(repos)).To(Equal([]string{"a", "b", "c"}))
+		Expect(repos.Sort("pulls")).To(Succeed())
+		Expect(names(repos)).To(Equal([]string{"c", "a", "b"}))
+		Expect(repos.Sort("stars")).To(Succeed())
+		Expect(names(repos)).To(Equal([]string{"b", "a", "c"}))
+		Expect(repos.Sort("updated")).To(Succeed())
+		Expect(names(repos)).To(Equal([]string{"a", "b", "c"}))
+		Expect(repos.Sort("size")).NotTo(Succeed())
+	})
+
+	It("should read the summary fields of a listing", func() {
+		var repo api.UserRepository
+		data := `{"namespace":"user","name":"a","description":"An app","is_private":true,"status":1,"status_description":"active","star_count":4,"pull_count":1200,"last_updated":"2020-05-01T10:00:00Z"}`
+		Expect(json.Unmarshal([]byte(data), &repo)).To(Succeed())
+		Expect(repo.StarCount).To(Equal(4))
+		Expect(repo.PullCount).To(Equal(1200))
+		Expect(repo.IsPrivate).To(BeTrue())
+		Expect(repo.LastUpdated).NotTo(BeNil())
+		Expect(repo.StatusName()).To(Equal("active"))
+
+		f, err := api.ParseFilter(`private && pulls > 1000 && description =~ "app" && status == 1`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Match(repo)).To(BeTrue())
+	})
+})
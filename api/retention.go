@@ -0,0 +1,277 @@
+package api
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//RetentionConfig is a file of retention policies.
+type RetentionConfig struct {
+	Policies []*RetentionPolicy `yaml:"policies"`
+}
+
+//RetentionPolicy decides which tags of some repositories are kept and which are deleted.
+//A tag is kept if any keep rule selects it, otherwise it's deleted if any delete rule selects it.
+//Tags that no rule selects are handled by the default, which is to keep them.
+type RetentionPolicy struct {
+	//Repositories the policy applies to, like user/repo or user/*
+	Repositories []string        `yaml:"repositories"`
+	Keep         []RetentionRule `yaml:"keep"`
+	Delete       []RetentionRule `yaml:"delete"`
+	//keep or delete
+	Default string `yaml:"default"`
+}
+
+//RetentionRule selects tags. All the filters that are set have to match,
+//then most_recent and newest_per narrow down the selected tags.
+type RetentionRule struct {
+	//A glob for tag names, like release-*
+	Match string `yaml:"match"`
+	//A regular expression for tag names
+	Regex string `yaml:"regex"`
+	//An age like 14d, only tags last updated before it are selected
+	OlderThan string `yaml:"older_than"`
+	//An age like 14d, only tags last updated after it are selected
+	NewerThan string `yaml:"newer_than"`
+	//Only select this many of the most recently updated tags
+	MostRecent int `yaml:"most_recent"`
+	//major, minor or patch, only select the newest version tag of each release line
+	NewestPer string `yaml:"newest_per"`
+}
+
+//RetentionDecision is what a policy decided for a tag.
+type RetentionDecision struct {
+	Tag    Tag
+	Delete bool
+	//The rule that decided it
+	Reason string
+}
+
+//ParseRetentionConfig parses a yaml file of retention policies.
+func ParseRetentionConfig(content []byte) (*RetentionConfig, error) {
+	var config RetentionConfig
+	err := yaml.UnmarshalStrict(content, &config)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range config.Policies {
+		if len(p.Repositories) == 0 {
+			return nil, fmt.Errorf("policy %d has no repositories", i+1)
+		}
+		if p.Default != "" && p.Default != "keep" && p.Default != "delete" {
+			return nil, fmt.Errorf("policy %d has an invalid default %s, use keep or delete", i+1, p.Default)
+		}
+		for _, rule := range append(append([]RetentionRule{}, p.Keep...), p.Delete...) {
+			_, err = rule.selector(time.Now())
+			if err != nil {
+				return nil, fmt.Errorf("policy %d: %v", i+1, err)
+			}
+		}
+	}
+	return &config, nil
+}
+
+//Matches checks if the policy applies to a repository.
+func (p *RetentionPolicy) Matches(repository string) bool {
+	for _, pattern := range p.Repositories {
+		if matched, _ := path.Match(pattern, repository); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//Plan decides which tags are kept and which are deleted, as of the given time.
+func (p *RetentionPolicy) Plan(tags TagList, now time.Time) ([]RetentionDecision, error) {
+	reasons := make(map[string]string)
+	deleted := make(map[string]bool)
+	for _, rule := range p.Keep {
+		selected, err := rule.Select(tags, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range selected {
+			if _, ok := reasons[t.Name]; !ok {
+				reasons[t.Name] = "keep " + rule.String()
+			}
+		}
+	}
+	for _, rule := range p.Delete {
+		selected, err := rule.Select(tags, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range selected {
+			if _, ok := reasons[t.Name]; !ok {
+				reasons[t.Name] = "delete " + rule.String()
+				deleted[t.Name] = true
+			}
+		}
+	}
+	var output []RetentionDecision
+	for _, t := range tags {
+		reason, ok := reasons[t.Name]
+		isDeleted := deleted[t.Name]
+		if !ok {
+			isDeleted = p.Default == "delete"
+			reason = "default"
+		}
+		output = append(output, RetentionDecision{Tag: t, Delete: isDeleted, Reason: reason})
+	}
+	return output, nil
+}
+
+//Select gets the tags that the rule selects, as of the given time.
+func (r *RetentionRule) Select(tags TagList, now time.Time) (TagList, error) {
+	matches, err := r.selector(now)
+	if err != nil {
+		return nil, err
+	}
+	var selected TagList
+	for _, t := range tags {
+		if matches(&t) {
+			selected = append(selected, t)
+		}
+	}
+	if r.NewestPer != "" {
+		selected = newestPerVersion(selected, r.NewestPer)
+	}
+	if r.MostRecent > 0 {
+		_ = selected.Sort("date")
+		if len(selected) > r.MostRecent {
+			selected = selected[:r.MostRecent]
+		}
+	}
+	return selected, nil
+}
+
+func (r *RetentionRule) selector(now time.Time) (func(*Tag) bool, error) {
+	var filters []func(*Tag) bool
+	if r.Match != "" {
+		pattern := r.Match
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+		}
+		filters = append(filters, func(t *Tag) bool {
+			matched, _ := path.Match(pattern, t.Name)
+			return matched
+		})
+	}
+	if r.Regex != "" {
+		rx, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(t *Tag) bool { return rx.MatchString(t.Name) })
+	}
+	if r.OlderThan != "" {
+		age, err := ParseAge(r.OlderThan)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(t *Tag) bool { return t.LastUpdated.Before(now.Add(-age)) })
+	}
+	if r.NewerThan != "" {
+		age, err := ParseAge(r.NewerThan)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(t *Tag) bool { return t.LastUpdated.After(now.Add(-age)) })
+	}
+	if _, ok := versionParts[r.NewestPer]; !ok && r.NewestPer != "" {
+		return nil, fmt.Errorf("invalid newest_per %s, use major, minor or patch", r.NewestPer)
+	}
+	if r.MostRecent < 0 {
+		return nil, fmt.Errorf("most_recent can't be negative")
+	}
+	return func(t *Tag) bool {
+		for _, f := range filters {
+			if !f(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (r RetentionRule) String() string {
+	var parts []string
+	if r.Match != "" {
+		parts = append(parts, "match "+r.Match)
+	}
+	if r.Regex != "" {
+		parts = append(parts, "regex "+r.Regex)
+	}
+	if r.OlderThan != "" {
+		parts = append(parts, "older than "+r.OlderThan)
+	}
+	if r.NewerThan != "" {
+		parts = append(parts, "newer than "+r.NewerThan)
+	}
+	if r.NewestPer != "" {
+		parts = append(parts, "newest per "+r.NewestPer)
+	}
+	if r.MostRecent > 0 {
+		parts = append(parts, fmt.Sprintf("%d most recent", r.MostRecent))
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ", ")
+}
+
+var versionParts = map[string]int{"major": 1, "minor": 2, "patch": 3}
+
+//newestPerVersion gets the newest version tag of each release line, tags that are not versions are left out
+func newestPerVersion(tags TagList, per string) TagList {
+	newest := make(map[string]*Tag)
+	versions := make(map[string]*Version)
+	var lines []string
+	for i := range tags {
+		t := &tags[i]
+		v, err := ParseVersion(t.Name)
+		if err != nil {
+			continue
+		}
+		versions[t.Name] = v
+		line := v.Suffix
+		for i := 0; i < versionParts[per] && i < len(v.Numbers); i++ {
+			line += "." + strconv.Itoa(v.Numbers[i])
+		}
+		current, ok := newest[line]
+		if !ok {
+			lines = append(lines, line)
+		}
+		if !ok || v.Compare(versions[current.Name]) > 0 ||
+			(v.Compare(versions[current.Name]) == 0 && t.LastUpdated.After(current.LastUpdated)) {
+			newest[line] = t
+		}
+	}
+	sort.Strings(lines)
+	var output TagList
+	for _, line := range lines {
+		output = append(output, *newest[line])
+	}
+	return output
+}
+
+//ParseAge parses a duration that can also be given in days or weeks, like 30d or 2w.
+func ParseAge(str string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if strings.HasSuffix(str, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(str, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %s", str)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(str)
+}
@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Retention", func() {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	tag := func(name string, age time.Duration) api.Tag {
+		return api.Tag{Name: name, LastUpdated: now.Add(-age)}
+	}
+	day := 24 * time.Hour
+	tags := api.TagList{
+		tag("latest", 0),
+		tag("1.2.1", day),
+		tag("1.2.0", 2*day),
+		tag("1.1.5", 3*day),
+		tag("1.1.4", 4*day),
+		tag("release-2019", 300*day),
+		tag("pr-12", 20*day),
+		tag("pr-13", 2*day),
+		tag("build-99", 100*day),
+	}
+	policy := []byte(`
+policies:
+  - repositories: [user/*]
+    keep:
+      - most_recent: 2
+      - newest_per: minor
+      - match: release-*
+    delete:
+      - match: pr-*
+        older_than: 14d
+      - regex: ^build-
+`)
+
+	decisions := func(p *api.RetentionPolicy) map[string]string {
+		plan, err := p.Plan(tags, now)
+		Expect(err).NotTo(HaveOccurred())
+		output := make(map[string]string)
+		for _, d := range plan {
+			if d.Delete {
+				output[d.Tag.Name] = "delete: " + d.Reason
+			} else {
+				output[d.Tag.Name] = "keep: " + d.Reason
+			}
+		}
+		return output
+	}
+
+	It("should plan what to keep and delete", func() {
+		config, err := api.ParseRetentionConfig(policy)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Policies[0].Matches("user/app")).To(BeTrue())
+		Expect(config.Policies[0].Matches("other/app")).To(BeFalse())
+		Expect(decisions(config.Policies[0])).To(Equal(map[string]string{
+			"latest":       "keep: keep 2 most recent",
+			"1.2.1":        "keep: keep 2 most recent",
+			"1.2.0":        "keep: default",
+			"1.1.5":        "keep: keep newest per minor",
+			"1.1.4":        "keep: default",
+			"release-2019": "keep: keep match release-*",
+			"pr-12":        "delete: delete match pr-*, older than 14d",
+			"pr-13":        "keep: default",
+			"build-99":     "delete: delete regex ^build-",
+		}))
+	})
+
+	It("should delete unmatched tags by default if asked to", func() {
+		p := &api.RetentionPolicy{Keep: []api.RetentionRule{{NewestPer: "major"}}, Default: "delete"}
+		result := decisions(p)
+		Expect(result["1.2.1"]).To(Equal("keep: keep newest per major"))
+		Expect(result["1.2.0"]).To(Equal("delete: default"))
+		Expect(result["latest"]).To(Equal("delete: default"))
+	})
+
+	It("should reject invalid policies", func() {
+		for _, content := range []string{
+			"policies: [{keep: [{match: a}]}]",
+			"policies: [{repositories: [a/b], keep: [{newest_per: week}]}]",
+			"policies: [{repositories: [a/b], delete: [{older_than: soon}]}]",
+			"policies: [{repositories: [a/b], default: maybe}]",
+			"policies: [{repositories: [a/b], keep: [{unknown: 1}]}]",
+		} {
+			_, err := api.ParseRetentionConfig([]byte(content))
+			Expect(err).To(HaveOccurred(), content)
+		}
+	})
+})
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"os"
+)
+
+const partialSuffix = ".partial"
+
+//OpenBlob streams a blob starting from the given offset.
+//The returned offset is where the stream actually starts, since registries may ignore range requests.
+func (rc *RegistryClient) OpenBlob(repo, digest string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequest("GET", rc.getRoute(repo, "blobs", digest), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := rc.do(req, pullScope(repo))
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode != http.StatusPartialContent {
+		offset = 0
+	}
+	return res.Body, offset, nil
+}
+
+//SaveImage downloads an image with all its blobs into an OCI image layout.
+//If a platform is given then indexes are resolved to that platform, otherwise all the platforms are saved.
+func (rc *RegistryClient) SaveImage(repo, reference string, platform *Platform, layout *ImageLayout, refName string) (*RawManifest, error) {
+	manifest, err := rc.GetManifest(repo, reference)
+	if err != nil {
+		return nil, err
+	}
+	if platform != nil {
+		manifest, err = rc.ResolvePlatform(repo, manifest, platform)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = rc.saveManifest(repo, manifest, layout)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, layout.AddManifest(manifest.Descriptor(), refName)
+}
+
+func (rc *RegistryClient) saveManifest(repo string, manifest *RawManifest, layout *ImageLayout) error {
+	parsed, err := manifest.Parse()
+	if err != nil {
+		return err
+	}
+	if parsed.IsIndex() {
+		for _, m := range parsed.Manifests {
+			child, err := rc.GetManifest(repo, m.Digest)
+			if err != nil {
+				return err
+			}
+			err = rc.saveManifest(repo, child, layout)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		blobs := parsed.Layers
+		if parsed.Config != nil {
+			blobs = append([]Descriptor{*parsed.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			err = rc.downloadBlob(repo, blob, layout)
+			if err != nil {
+				return fmt.Errorf("could not download %s: %v", blob.Digest, err)
+			}
+		}
+	}
+	_, err = layout.WriteBlob(manifest.Content)
+	return err
+}
+
+//downloadBlob streams a blob into the layout, verifying its digest.
+//Blobs are written to a partial file first, so that interrupted downloads are resumed.
+func (rc *RegistryClient) downloadBlob(repo string, desc Descriptor, layout *ImageLayout) error {
+	target := layout.blobPath(desc.Digest)
+	if layout.HasBlob(desc.Digest) {
+		return nil
+	}
+	partial := target + partialSuffix
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	offset, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	if offset > desc.Size || (offset == desc.Size && hashDigest(hash.Sum(nil)) != desc.Digest) {
+		//The partial file is broken, so we start over
+		offset = 0
+	}
+	if offset == 0 || offset < desc.Size {
+		body, start, err := rc.OpenBlob(repo, desc.Digest, offset)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		if start == 0 && offset != 0 {
+			log.Infof("Registry doesn't support resuming, downloading %s again", desc.Digest)
+		}
+		if start == 0 {
+			hash.Reset()
+			if err = f.Truncate(0); err != nil {
+				return err
+			}
+			if _, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		} else {
+			log.Infof("Resuming %s from %d bytes", desc.Digest, start)
+		}
+		_, err = io.Copy(io.MultiWriter(f, hash), body)
+		if err != nil {
+			return err
+		}
+	}
+	actual := hashDigest(hash.Sum(nil))
+	if actual != desc.Digest {
+		_ = os.Remove(partial)
+		return fmt.Errorf("digest mismatch, got %s", actual)
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	log.Infof("Downloaded %s", desc.Digest)
+	return os.Rename(partial, target)
+}
+
+func hashDigest(sum []byte) string {
+	return "sha256:" + hex.EncodeToString(sum)
+}
@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Save", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	var dir string
+	var index string
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+		dir, _ = ioutil.TempDir("", "layout")
+		amd := pushImage(registry, "user/repo", "", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "base", "amd-layer")
+		arm := pushImage(registry, "user/repo", "", api.ImageConfig{Architecture: "arm64", OS: "linux"}, "base", "arm-layer")
+		index = pushIndex(registry, "user/repo", "1.0", map[string]string{"linux/amd64": amd, "linux/arm64": arm})
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+		_ = os.RemoveAll(dir)
+	})
+
+	It("should save all the platforms of an image", func() {
+		layout, err := api.NewImageLayout(dir)
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := client.SaveImage("user/repo", "1.0", nil, layout, "1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Digest).To(Equal(index))
+		idx, err := layout.Index()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(idx.Manifests).To(HaveLen(1))
+		Expect(idx.Manifests[0].Digest).To(Equal(index))
+		Expect(idx.Manifests[0].Annotations["org.opencontainers.image.ref.name"]).To(Equal("1.0"))
+		for _, blob := range []string{"base", "amd-layer", "arm-layer"} {
+			Expect(layout.HasBlob(digestOf([]byte(blob)))).To(BeTrue())
+		}
+		Expect(layout.HasBlob(index)).To(BeTrue())
+	})
+
+	It("should resume partial downloads and write docker archives", func() {
+		layout, _ := api.NewImageLayout(dir)
+		layer := digestOf([]byte("amd-layer"))
+		partial := filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(layer, "sha256:")+".partial")
+		Expect(ioutil.WriteFile(partial, []byte("amd-"), 0644)).To(Succeed())
+		amd64, _ := api.ParsePlatform("linux/amd64")
+		manifest, err := client.SaveImage("user/repo", "1.0", amd64, layout, "1.0")
+		Expect(err).NotTo(HaveOccurred())
+		content, err := layout.ReadBlob(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("amd-layer"))
+		Expect(layout.HasBlob(digestOf([]byte("arm-layer")))).To(BeFalse())
+
+		parsed, _ := manifest.Parse()
+		Expect(layout.AddDockerManifest("user/repo:1.0", parsed)).To(Succeed())
+		var buff bytes.Buffer
+		Expect(layout.WriteTar(&buff)).To(Succeed())
+		reader := tar.NewReader(&buff)
+		files := make(map[string][]byte)
+		for {
+			header, err := reader.Next()
+			if err != nil {
+				break
+			}
+			files[header.Name], _ = ioutil.ReadAll(reader)
+		}
+		Expect(files).To(HaveKey("oci-layout"))
+		Expect(files).To(HaveKey("index.json"))
+		var dockerManifest []struct {
+			Config   string
+			RepoTags []string
+			Layers   []string
+		}
+		Expect(json.Unmarshal(files["manifest.json"], &dockerManifest)).To(Succeed())
+		Expect(dockerManifest[0].RepoTags).To(Equal([]string{"user/repo:1.0"}))
+		Expect(files).To(HaveKey(dockerManifest[0].Config))
+		Expect(files[dockerManifest[0].Layers[1]]).To(Equal([]byte("amd-layer")))
+	})
+
+	It("should reject corrupted blobs", func() {
+		layout, _ := api.NewImageLayout(dir)
+		registry.blobs[digestOf([]byte("base"))] = []byte("corrupted")
+		_, err := client.SaveImage("user/repo", "1.0", nil, layout, "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("digest mismatch"))
+	})
+})
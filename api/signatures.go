@@ -0,0 +1,371 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+//oidEd25519 is the object identifier of ed25519 public keys
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+const (
+	cosignSignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	cosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	MediaTypeDSSEEnvelope       = "application/vnd.dsse.envelope.v1+json"
+	MediaTypeSimpleSigning      = "application/vnd.dev.cosign.simplesigning.v1+json"
+)
+
+//Signature is a signature or an attestation of an image, found either through a cosign tag or the referrers api.
+type Signature struct {
+	//signature, attestation or the artifact type of a referrer
+	Kind string `json:"kind"`
+	//Where the signature was found, the cosign tag or `referrers`
+	Source string `json:"source"`
+	//The digest of the manifest that holds the signature
+	ManifestDigest string            `json:"manifest_digest"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	//The in-toto predicate type of attestations
+	PredicateType string `json:"predicate_type,omitempty"`
+	Payload       []byte `json:"-"`
+	//The signing certificate, for keyless signatures
+	Certificate *x509.Certificate `json:"-"`
+	//The type of the payload, only set for attestations since they are signed with it
+	payloadType string
+	signatures  []dsseSignature
+}
+
+type dsseSignature struct {
+	KeyId string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+//Signer gets a description of who signed, from the certificate of keyless signatures or the key id.
+func (s *Signature) Signer() string {
+	if s.Certificate != nil {
+		var identities []string
+		identities = append(identities, s.Certificate.EmailAddresses...)
+		for _, u := range s.Certificate.URIs {
+			identities = append(identities, u.String())
+		}
+		if len(identities) > 0 {
+			return strings.Join(identities, ", ")
+		}
+		return s.Certificate.Subject.String()
+	}
+	for _, sig := range s.signatures {
+		if sig.KeyId != "" {
+			return sig.KeyId
+		}
+	}
+	return "key"
+}
+
+//cosignTag gets the tag that cosign uses for signatures or attestations of a digest
+func cosignTag(digest, suffix string) string {
+	return strings.Replace(digest, ":", "-", 1) + "." + suffix
+}
+
+//GetSignatures finds the cosign signatures and attestations of an image, and the artifacts that refer to it.
+func (rc *RegistryClient) GetSignatures(repo, digest string) ([]*Signature, error) {
+	var output []*Signature
+	for _, suffix := range []string{"sig", "att"} {
+		tag := cosignTag(digest, suffix)
+		manifest, err := rc.GetManifest(repo, tag)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		signatures, err := rc.readSignatureManifest(repo, manifest, tag)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, signatures...)
+	}
+	referrers, err := rc.GetReferrers(repo, digest, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range referrers {
+		manifest, err := rc.GetManifest(repo, ref.Digest)
+		if err != nil {
+			return nil, err
+		}
+		signatures, err := rc.readSignatureManifest(repo, manifest, "referrers")
+		if err != nil {
+			return nil, err
+		}
+		if len(signatures) == 0 {
+			//Artifacts that we can't read are still listed
+			signatures = append(signatures, &Signature{Source: "referrers", ManifestDigest: ref.Digest, Annotations: ref.Annotations})
+		}
+		for _, s := range signatures {
+			if ref.ArtifactType != "" {
+				s.Kind = ref.ArtifactType
+			}
+		}
+		output = append(output, signatures...)
+	}
+	return output, nil
+}
+
+//GetReferrers gets the manifests that refer to a digest through the OCI 1.1 referrers api, optionally filtered by artifact type.
+//Registries that don't support the api are asked for the index under the referrers tag schema, like sha256-<hex>,
+//and are treated as if there are no referrers if they don't have one.
+func (rc *RegistryClient) GetReferrers(repo, digest, artifactType string) ([]Descriptor, error) {
+	route := rc.getRoute(repo, "referrers", digest)
+	if artifactType != "" {
+		route += "?artifactType=" + artifactType
+	}
+	req, err := http.NewRequest("GET", route, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaTypeOCIIndex)
+	res, err := rc.do(req, pullScope(repo))
+	if isReferrersUnsupported(err) {
+		return rc.getReferrersTag(repo, digest, artifactType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var index Manifest
+	err = json.NewDecoder(res.Body).Decode(&index)
+	if err != nil {
+		return nil, err
+	}
+	return filterReferrers(index, artifactType), nil
+}
+
+//getReferrersTag gets the referrers of a digest from the index that the referrers tag schema keeps them in
+func (rc *RegistryClient) getReferrersTag(repo, digest, artifactType string) ([]Descriptor, error) {
+	manifest, err := rc.GetManifest(repo, strings.Replace(digest, ":", "-", 1))
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index Manifest
+	err = json.Unmarshal(manifest.Content, &index)
+	if err != nil {
+		return nil, err
+	}
+	return filterReferrers(index, artifactType), nil
+}
+
+//isReferrersUnsupported checks if the registry answered a referrers request in a way that means it has no referrers api
+func isReferrersUnsupported(err error) bool {
+	rerr, ok := err.(*RegistryError)
+	if !ok {
+		return false
+	}
+	switch rerr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotAcceptable:
+		return true
+	}
+	return false
+}
+
+func filterReferrers(index Manifest, artifactType string) []Descriptor {
+	var output []Descriptor
+	for _, m := range index.Manifests {
+		if artifactType == "" || m.ArtifactType == artifactType {
+			output = append(output, m)
+		}
+	}
+	return output
+}
+
+func (rc *RegistryClient) readSignatureManifest(repo string, manifest *RawManifest, source string) ([]*Signature, error) {
+	parsed, err := manifest.Parse()
+	if err != nil {
+		return nil, err
+	}
+	var output []*Signature
+	for _, layer := range parsed.Layers {
+		isSignature := layer.Annotations[cosignSignatureAnnotation] != ""
+		if !isSignature && layer.MediaType != MediaTypeDSSEEnvelope {
+			continue
+		}
+		content, err := rc.GetBlob(repo, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		s := &Signature{Source: source, ManifestDigest: manifest.Digest, Annotations: make(map[string]string)}
+		for k, v := range layer.Annotations {
+			//The signature material is too long to be listed
+			if !strings.HasPrefix(k, "dev.sigstore.cosign/") && k != cosignSignatureAnnotation {
+				s.Annotations[k] = v
+			}
+		}
+		if isSignature {
+			s.Kind = "signature"
+			s.Payload = content
+			s.signatures = []dsseSignature{{Sig: layer.Annotations[cosignSignatureAnnotation]}}
+			var payload simpleSigningPayload
+			if json.Unmarshal(content, &payload) == nil {
+				for k, v := range payload.Optional {
+					s.Annotations[k] = fmt.Sprint(v)
+				}
+			}
+		} else {
+			s.Kind = "attestation"
+			err = s.readEnvelope(content)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if certPem := layer.Annotations[cosignCertificateAnnotation]; certPem != "" {
+			block, _ := pem.Decode([]byte(certPem))
+			if block != nil {
+				s.Certificate, _ = x509.ParseCertificate(block.Bytes)
+			}
+		}
+		output = append(output, s)
+	}
+	return output, nil
+}
+
+func (s *Signature) readEnvelope(content []byte) error {
+	var envelope dsseEnvelope
+	err := json.Unmarshal(content, &envelope)
+	if err != nil {
+		return err
+	}
+	s.payloadType = envelope.PayloadType
+	s.signatures = envelope.Signatures
+	s.Payload, err = base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return err
+	}
+	var statement InTotoStatement
+	if json.Unmarshal(s.Payload, &statement) == nil {
+		s.PredicateType = statement.PredicateType
+	}
+	return nil
+}
+
+//signedDigests gets the digests of the images that the payload is about, attestations can cover several subjects
+func (s *Signature) signedDigests() []string {
+	if s.payloadType != "" {
+		var statement InTotoStatement
+		if json.Unmarshal(s.Payload, &statement) != nil {
+			return nil
+		}
+		var digests []string
+		for _, subject := range statement.Subject {
+			if hex := subject.Digest["sha256"]; hex != "" {
+				digests = append(digests, "sha256:"+hex)
+			}
+		}
+		return digests
+	}
+	var payload simpleSigningPayload
+	if json.Unmarshal(s.Payload, &payload) != nil || payload.Critical.Image.DockerManifestDigest == "" {
+		return nil
+	}
+	return []string{payload.Critical.Image.DockerManifestDigest}
+}
+
+//signedContent gets the bytes that were signed, which is the DSSE pre-authentication encoding for attestations
+func (s *Signature) signedContent() []byte {
+	if s.payloadType == "" {
+		return s.Payload
+	}
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(s.payloadType), s.payloadType, len(s.Payload), s.Payload))
+}
+
+//Verify verifies the signature with a public key, and checks that the given image digest is one of the images it was made for.
+func (s *Signature) Verify(key crypto.PublicKey, imageDigest string) error {
+	if len(s.signatures) == 0 {
+		return errors.New("no signatures found")
+	}
+	signed := s.signedDigests()
+	covered := false
+	for _, d := range signed {
+		covered = covered || d == imageDigest
+	}
+	if !covered {
+		return fmt.Errorf("the signature is for %s, not %s", strings.Join(signed, ", "), imageDigest)
+	}
+	content := s.signedContent()
+	hash := sha256.Sum256(content)
+	for _, sig := range s.signatures {
+		raw, err := base64.StdEncoding.DecodeString(sig.Sig)
+		if err != nil {
+			continue
+		}
+		var valid bool
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			var ecdsaSig struct{ R, S *big.Int }
+			if _, err := asn1.Unmarshal(raw, &ecdsaSig); err != nil {
+				continue
+			}
+			valid = ecdsa.Verify(k, hash[:], ecdsaSig.R, ecdsaSig.S)
+		case *rsa.PublicKey:
+			valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], raw) == nil
+		case ed25519.PublicKey:
+			valid = ed25519.Verify(k, content, raw)
+		default:
+			return fmt.Errorf("unsupported key type %T", key)
+		}
+		if valid {
+			return nil
+		}
+	}
+	return errors.New("invalid signature")
+}
+
+//ParsePublicKey parses a PEM encoded public key, like the cosign.pub files that cosign generates.
+func ParsePublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+	//Older go versions can't parse ed25519 keys, so those are read here
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err == nil && info.Algorithm.Algorithm.Equal(oidEd25519) {
+		if len(info.PublicKey.Bytes) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		return ed25519.PublicKey(info.PublicKey.Bytes), nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,161 @@
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+func sign(key *ecdsa.PrivateKey, content []byte) string {
+	hash := sha256.Sum256(content)
+	r, s, _ := ecdsa.Sign(rand.Reader, key, hash[:])
+	sig, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+//pushSignatures stores a cosign signature and attestation for the image digest, signed with the given key.
+//The attestation also covers the other subjects, which are listed before the image.
+func pushSignatures(registry *fakeRegistry, repo, digest string, key *ecdsa.PrivateKey, otherSubjects ...string) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":{"team":"infra"}}`, repo, digest))
+	var subjects []string
+	for _, d := range append(otherSubjects, digest) {
+		subjects = append(subjects, fmt.Sprintf(`{"name":"%s","digest":{"sha256":"%s"}}`, repo, strings.TrimPrefix(d, "sha256:")))
+	}
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","subject":[%s],"predicate":{}}`, strings.Join(subjects, ",")))
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len("application/vnd.in-toto+json"), "application/vnd.in-toto+json", len(statement), statement)
+	envelope, _ := json.Marshal(map[string]interface{}{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures":  []map[string]string{{"keyid": "", "sig": sign(key, []byte(pae))}},
+	})
+	layers := map[string]api.Descriptor{
+		"sig": {
+			MediaType:   api.MediaTypeSimpleSigning,
+			Digest:      registry.addBlob(payload),
+			Size:        int64(len(payload)),
+			Annotations: map[string]string{"dev.cosignproject.cosign/signature": sign(key, payload)},
+		},
+		"att": {
+			MediaType:   api.MediaTypeDSSEEnvelope,
+			Digest:      registry.addBlob(envelope),
+			Size:        int64(len(envelope)),
+			Annotations: map[string]string{"predicateType": "https://slsa.dev/provenance/v0.2"},
+		},
+	}
+	for suffix, layer := range layers {
+		manifest, _ := json.Marshal(api.Manifest{
+			SchemaVersion: 2,
+			MediaType:     api.MediaTypeOCIManifest,
+			Config:        &api.Descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: registry.addBlob([]byte("{}")), Size: 2},
+			Layers:        []api.Descriptor{layer},
+		})
+		registry.addManifest(repo, strings.Replace(digest, ":", "-", 1)+"."+suffix, api.MediaTypeOCIManifest, manifest)
+	}
+}
+
+var _ = Describe("Signatures", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	var digest string
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+		digest = pushImage(registry, "user/repo", "latest", api.ImageConfig{Architecture: "amd64", OS: "linux"}, "layer")
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should find and verify cosign signatures", func() {
+		pushSignatures(registry, "user/repo", digest, key)
+		signatures, err := client.GetSignatures("user/repo", digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signatures).To(HaveLen(2))
+		Expect(signatures[0].Kind).To(Equal("signature"))
+		Expect(signatures[0].Annotations).To(Equal(map[string]string{"team": "infra"}))
+		Expect(signatures[1].Kind).To(Equal("attestation"))
+		Expect(signatures[1].PredicateType).To(Equal("https://slsa.dev/provenance/v0.2"))
+
+		der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		pub, err := api.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		Expect(err).NotTo(HaveOccurred())
+		for _, s := range signatures {
+			Expect(s.Verify(pub, digest)).To(Succeed())
+			Expect(s.Verify(&otherKey.PublicKey, digest)).NotTo(Succeed())
+			Expect(s.Verify(pub, "sha256:"+strings.Repeat("0", 64))).NotTo(Succeed())
+		}
+	})
+
+	It("should verify attestations that cover several subjects", func() {
+		pushSignatures(registry, "user/repo", digest, key, "sha256:"+strings.Repeat("1", 64))
+		signatures, err := client.GetSignatures("user/repo", digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signatures[1].Kind).To(Equal("attestation"))
+		Expect(signatures[1].Verify(&key.PublicKey, digest)).To(Succeed())
+		Expect(signatures[1].Verify(&key.PublicKey, "sha256:"+strings.Repeat("1", 64))).To(Succeed())
+		Expect(signatures[1].Verify(&key.PublicKey, "sha256:"+strings.Repeat("0", 64))).NotTo(Succeed())
+	})
+
+	It("should fall back to the referrers tag schema", func() {
+		registry.referrersStatus = http.StatusMethodNotAllowed
+		pushSignatures(registry, "user/repo", digest, key)
+		signatures, err := client.GetSignatures("user/repo", digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signatures).To(HaveLen(2))
+
+		artifact, _ := json.Marshal(api.Manifest{
+			SchemaVersion: 2,
+			MediaType:     api.MediaTypeOCIManifest,
+			ArtifactType:  "application/vnd.cncf.notary.signature",
+			Config:        &api.Descriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: registry.addBlob([]byte("{}")), Size: 2},
+		})
+		artifactDigest := registry.addManifest("user/repo", "", api.MediaTypeOCIManifest, artifact)
+		index, _ := json.Marshal(api.Manifest{
+			SchemaVersion: 2,
+			MediaType:     api.MediaTypeOCIIndex,
+			Manifests:     []api.Descriptor{{MediaType: api.MediaTypeOCIManifest, Digest: artifactDigest, ArtifactType: "application/vnd.cncf.notary.signature"}},
+		})
+		registry.addManifest("user/repo", strings.Replace(digest, ":", "-", 1), api.MediaTypeOCIIndex, index)
+		signatures, err = client.GetSignatures("user/repo", digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signatures).To(HaveLen(3))
+		Expect(signatures[2].Kind).To(Equal("application/vnd.cncf.notary.signature"))
+	})
+
+	It("should list referrers", func() {
+		subject := api.Descriptor{MediaType: api.MediaTypeOCIManifest, Digest: digest}
+		artifact, _ := json.Marshal(api.Manifest{
+			SchemaVersion: 2,
+			MediaType:     api.MediaTypeOCIManifest,
+			ArtifactType:  "application/vnd.cncf.notary.signature",
+			Config:        &api.Descriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: registry.addBlob([]byte("{}")), Size: 2},
+			Subject:       &subject,
+			Annotations:   map[string]string{"signer": "ci"},
+		})
+		registry.addManifest("user/repo", "", api.MediaTypeOCIManifest, artifact)
+		signatures, err := client.GetSignatures("user/repo", digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signatures).To(HaveLen(1))
+		Expect(signatures[0].Kind).To(Equal("application/vnd.cncf.notary.signature"))
+		Expect(signatures[0].Source).To(Equal("referrers"))
+		Expect(signatures[0].Annotations["signer"]).To(Equal("ci"))
+	})
+})
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -18,6 +22,8 @@ type Tag struct {
 	Repository int    `json:"repository"`
 	FullSize   int    `json:"full_size"`
 	V2         bool   `json:"v2"`
+	//The digest of the manifest or index the tag points to
+	Digest string `json:"digest"`
 }
 
 type TaggedImage struct {
@@ -53,3 +59,232 @@ func (tags TagList) getByName(nm string, exactMatch bool) *Tag {
 	}
 	return nil
 }
+
+//Platform gets the platform of the image.
+func (i *TaggedImage) Platform() *Platform {
+	p := &Platform{OS: i.Os, Architecture: i.Architecture}
+	if i.Variant != nil {
+		p.Variant = *i.Variant
+	}
+	if i.OsVersion != nil {
+		p.OSVersion = *i.OsVersion
+	}
+	return p
+}
+
+//Platforms gets the platforms that the tag has images for.
+func (t *Tag) Platforms() []*Platform {
+	var output []*Platform
+	for _, img := range t.Images {
+		if img.Os == "unknown" || img.Architecture == "unknown" {
+			//Attestations and other artifacts have no platform
+			continue
+		}
+		output = append(output, img.Platform())
+	}
+	return output
+}
+
+//GetImage gets the image of the tag for the given platform, nil if there is none.
+func (t *Tag) GetImage(platform *Platform) *TaggedImage {
+	for i := range t.Images {
+		if t.Images[i].Platform().Matches(platform) {
+			return &t.Images[i]
+		}
+	}
+	return nil
+}
+
+//FilterPlatform gets the tags that have an image for the given platform.
+func (tags TagList) FilterPlatform(platform *Platform) TagList {
+	var output TagList
+	for _, t := range tags {
+		if t.GetImage(platform) != nil {
+			output = append(output, t)
+		}
+	}
+	return output
+}
+
+//MissingPlatforms gets the required platforms that each tag has no image for.
+//Tags that have all the platforms are not included.
+func (tags TagList) MissingPlatforms(required []*Platform) map[string][]*Platform {
+	output := make(map[string][]*Platform)
+	for _, t := range tags {
+		for _, p := range required {
+			if t.GetImage(p) == nil {
+				output[t.Name] = append(output[t.Name], p)
+			}
+		}
+	}
+	return output
+}
+
+//FilterNames gets the tags whose name matches any of the patterns.
+//Patterns are globs like 1.*-alpine, or regular expressions if isRegex is set.
+func (tags TagList) FilterNames(patterns []string, isRegex bool) (TagList, error) {
+	var matchers []func(string) bool
+	for _, pattern := range patterns {
+		if isRegex {
+			rx, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, rx.MatchString)
+			continue
+		}
+		pattern := pattern
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+		}
+		matchers = append(matchers, func(name string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		})
+	}
+	var output TagList
+	for _, t := range tags {
+		for _, match := range matchers {
+			if match(t.Name) {
+				output = append(output, t)
+				break
+			}
+		}
+	}
+	return output, nil
+}
+
+//UpdatedBetween gets the tags that were last updated after since and before before. Zero times are not checked.
+func (tags TagList) UpdatedBetween(since, before time.Time) TagList {
+	var output TagList
+	for _, t := range tags {
+		if !since.IsZero() && t.LastUpdated.Before(since) {
+			continue
+		}
+		if !before.IsZero() && !t.LastUpdated.Before(before) {
+			continue
+		}
+		output = append(output, t)
+	}
+	return output
+}
+
+//Sort sorts the tags by name, semver, date or size.
+//Names are sorted alphabetically, the others have the newest or biggest tags first.
+//When sorting by semver, tags that are not versions come last.
+func (tags TagList) Sort(by string) error {
+	var less func(a, b *Tag) bool
+	switch by {
+	case "name":
+		less = func(a, b *Tag) bool { return a.Name < b.Name }
+	case "date":
+		less = func(a, b *Tag) bool { return a.LastUpdated.After(b.LastUpdated) }
+	case "size":
+		less = func(a, b *Tag) bool { return a.FullSize > b.FullSize }
+	case "semver":
+		versions := make(map[string]*Version)
+		for _, t := range tags {
+			versions[t.Name], _ = ParseVersion(t.Name)
+		}
+		less = func(a, b *Tag) bool {
+			va, vb := versions[a.Name], versions[b.Name]
+			switch {
+			case va == nil && vb == nil:
+				return a.Name < b.Name
+			case va == nil || vb == nil:
+				return vb == nil
+			}
+			return va.Compare(vb) > 0
+		}
+	default:
+		return fmt.Errorf("can't sort by %s, use name, semver, date or size", by)
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return less(&tags[i], &tags[j])
+	})
+	return nil
+}
+
+//TagGroup is a set of tags that point to the same image.
+type TagGroup struct {
+	Digest string
+	Tags   TagList
+}
+
+//digestKey gets what identifies the image of a tag, its digest or the digests of its images if the digest is unknown
+func (t *Tag) digestKey() string {
+	if t.Digest != "" {
+		return t.Digest
+	}
+	var digests []string
+	for _, img := range t.Images {
+		digests = append(digests, img.Digest)
+	}
+	sort.Strings(digests)
+	return strings.Join(digests, ",")
+}
+
+//GroupByDigest groups the tags that point to the same image, in the order the groups first appear.
+func (tags TagList) GroupByDigest() []*TagGroup {
+	var groups []*TagGroup
+	byDigest := make(map[string]*TagGroup)
+	for _, t := range tags {
+		key := t.digestKey()
+		group, ok := byDigest[key]
+		if !ok || key == "" {
+			group = &TagGroup{Digest: t.Digest}
+			byDigest[key] = group
+			groups = append(groups, group)
+		}
+		group.Tags = append(group.Tags, t)
+	}
+	for _, g := range groups {
+		sort.SliceStable(g.Tags, func(i, j int) bool {
+			return moreSpecific(&g.Tags[i], &g.Tags[j])
+		})
+	}
+	return groups
+}
+
+//moreSpecific checks if a tag names its image more precisely than another, like 1.25.3 compared to 1.25 or latest
+func moreSpecific(a, b *Tag) bool {
+	va, _ := ParseVersion(a.Name)
+	vb, _ := ParseVersion(b.Name)
+	switch {
+	case va != nil && vb == nil:
+		return true
+	case va == nil && vb != nil:
+		return false
+	case va != nil && len(va.Numbers) != len(vb.Numbers):
+		return len(va.Numbers) > len(vb.Numbers)
+	case len(a.Name) != len(b.Name):
+		return len(a.Name) > len(b.Name)
+	}
+	return a.Name < b.Name
+}
+
+//Canonical gets the most specific tag of the group.
+func (g *TagGroup) Canonical() *Tag {
+	return &g.Tags[0]
+}
+
+//Aliases gets the names of the other tags of the group.
+func (g *TagGroup) Aliases() []string {
+	var output []string
+	for _, t := range g.Tags[1:] {
+		output = append(output, t.Name)
+	}
+	return output
+}
+
+//Aliases gets the group of tags that point to the same image as the given tag, nil if there is no such tag.
+func (tags TagList) Aliases(name string) *TagGroup {
+	for _, g := range tags.GroupByDigest() {
+		for _, t := range g.Tags {
+			if t.Name == name {
+				return g
+			}
+		}
+	}
+	return nil
+}
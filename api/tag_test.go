@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+func taggedImage(os, arch, variant string) api.TaggedImage {
+	img := api.TaggedImage{Os: os, Architecture: arch}
+	if variant != "" {
+		img.Variant = &variant
+	}
+	return img
+}
+
+var _ = Describe("Tag", func() {
+	tags := api.TagList{
+		{Name: "1.0", Images: []api.TaggedImage{taggedImage("linux", "amd64", ""), taggedImage("linux", "arm64", "v8")}},
+		{Name: "0.9", Images: []api.TaggedImage{taggedImage("linux", "amd64", ""), taggedImage("unknown", "unknown", "")}},
+		{Name: "0.8", Images: []api.TaggedImage{taggedImage("linux", "arm", "v6")}},
+	}
+
+	It("should match platforms", func() {
+		arm64, _ := api.ParsePlatform("linux/arm64")
+		arm64v8, _ := api.ParsePlatform("linux/arm64/v8")
+		armv7, _ := api.ParsePlatform("linux/arm/v7")
+		Expect(tags.FilterPlatform(arm64)).To(HaveLen(1))
+		Expect(tags.FilterPlatform(arm64v8)).To(HaveLen(1))
+		Expect(tags.FilterPlatform(armv7)).To(BeEmpty())
+		Expect(arm64v8.Normalized().String()).To(Equal(arm64.String()))
+		Expect(armv7.Normalized().String()).To(Equal("linux/arm"))
+		Expect(tags[1].Platforms()).To(HaveLen(1))
+		_, err := api.ParsePlatform("linux")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should find missing platforms", func() {
+		amd64, _ := api.ParsePlatform("linux/amd64")
+		arm64, _ := api.ParsePlatform("linux/arm64")
+		missing := tags.MissingPlatforms([]*api.Platform{amd64, arm64})
+		Expect(missing).To(HaveLen(2))
+		Expect(missing["0.9"]).To(Equal([]*api.Platform{arm64}))
+		Expect(missing["0.8"]).To(Equal([]*api.Platform{amd64, arm64}))
+	})
+
+	It("should filter tag names", func() {
+		globbed, err := tags.FilterNames([]string{"0.*"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(globbed).To(HaveLen(2))
+		matched, err := tags.FilterNames([]string{`^1\.`, `8$`}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matched).To(HaveLen(2))
+		_, err = tags.FilterNames([]string{"["}, false)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should sort tags", func() {
+		now := time.Now()
+		sorted := api.TagList{
+			{Name: "latest", LastUpdated: now, FullSize: 10},
+			{Name: "1.9", LastUpdated: now.Add(-time.Hour), FullSize: 30},
+			{Name: "1.10-rc1", LastUpdated: now.Add(-2 * time.Hour), FullSize: 20},
+			{Name: "1.10", LastUpdated: now.Add(-3 * time.Hour)},
+		}
+		names := func() []string {
+			var output []string
+			for _, t := range sorted {
+				output = append(output, t.Name)
+			}
+			return output
+		}
+		Expect(sorted.Sort("semver")).To(Succeed())
+		Expect(names()).To(Equal([]string{"1.10", "1.10-rc1", "1.9", "latest"}))
+		Expect(sorted.Sort("size")).To(Succeed())
+		Expect(names()).To(Equal([]string{"1.9", "1.10-rc1", "latest", "1.10"}))
+		Expect(sorted.Sort("date")).To(Succeed())
+		Expect(names()).To(Equal([]string{"latest", "1.9", "1.10-rc1", "1.10"}))
+		Expect(sorted.UpdatedBetween(now.Add(-150*time.Minute), now)).To(HaveLen(2))
+		Expect(sorted.Sort("stars")).NotTo(Succeed())
+	})
+
+	It("should group tags by digest", func() {
+		aliased := api.TagList{
+			{Name: "latest", Digest: "sha256:a"},
+			{Name: "1", Digest: "sha256:a"},
+			{Name: "1.25.3", Digest: "sha256:a"},
+			{Name: "1.25", Digest: "sha256:a"},
+			{Name: "1.24.0", Digest: "sha256:b"},
+			{Name: "old", Images: []api.TaggedImage{{Digest: "sha256:c"}}},
+			{Name: "older", Images: []api.TaggedImage{{Digest: "sha256:c"}}},
+		}
+		groups := aliased.GroupByDigest()
+		Expect(groups).To(HaveLen(3))
+		Expect(groups[0].Canonical().Name).To(Equal("1.25.3"))
+		Expect(groups[0].Aliases()).To(Equal([]string{"1.25", "1", "latest"}))
+		Expect(groups[1].Aliases()).To(BeEmpty())
+		Expect(groups[2].Canonical().Name).To(Equal("older"))
+		Expect(aliased.Aliases("latest")).To(Equal(groups[0]))
+		Expect(aliased.Aliases("missing")).To(BeNil())
+	})
+})
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+//diffLine is a line of a diff, kind is ' ' for unchanged lines, '-' for removed and '+' for added ones.
+//from and to are the indexes of the line in the old and new text.
+type diffLine struct {
+	kind byte
+	text string
+	from int
+	to   int
+}
+
+//splitLines splits a text into lines, a trailing newline doesn't start a new line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+//diffLines finds the smallest set of removed and added lines that turn a into b, using their longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var output []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			output = append(output, diffLine{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			output = append(output, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			output = append(output, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+	return output
+}
+
+//UnifiedDiff creates a unified diff between two texts, with the given number of unchanged lines around each change.
+//The diff is empty if the texts have the same lines.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+	//Find the ranges of lines around the changes, merging the ones that overlap
+	var hunks [][2]int
+	for ix, line := range lines {
+		if line.kind == ' ' {
+			continue
+		}
+		start, end := ix-context, ix+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1][1] {
+			hunks[len(hunks)-1][1] = end
+		} else {
+			hunks = append(hunks, [2]int{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+	for _, hunk := range hunks {
+		fromStart, toStart := lines[hunk[0]].from, lines[hunk[0]].to
+		fromCount, toCount := 0, 0
+		for _, line := range lines[hunk[0]:hunk[1]] {
+			if line.kind != '+' {
+				fromCount++
+			}
+			if line.kind != '-' {
+				toCount++
+			}
+		}
+		//Empty ranges point to the line before them, the others start at 1
+		if fromCount > 0 {
+			fromStart++
+		}
+		if toCount > 0 {
+			toStart++
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount))
+		for _, line := range lines[hunk[0]:hunk[1]] {
+			sb.WriteByte(line.kind)
+			sb.WriteString(line.text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionRx = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:[-+_](.+))?$`)
+
+//Version is a version parsed from a tag name, like 1.2.3, v2 or 3.12-slim.
+type Version struct {
+	Numbers []int
+	//Anything after the version numbers, like slim or rc1
+	Suffix string
+}
+
+//ParseVersion parses a tag name as a version.
+func ParseVersion(tag string) (*Version, error) {
+	m := versionRx.FindStringSubmatch(tag)
+	if m == nil {
+		return nil, fmt.Errorf("%s is not a version", tag)
+	}
+	v := &Version{Suffix: m[2]}
+	for _, part := range strings.Split(m[1], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a version", tag)
+		}
+		v.Numbers = append(v.Numbers, n)
+	}
+	return v, nil
+}
+
+func (v *Version) String() string {
+	var parts []string
+	for _, n := range v.Numbers {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	str := strings.Join(parts, ".")
+	if v.Suffix != "" {
+		str += "-" + v.Suffix
+	}
+	return str
+}
+
+//Compare compares two versions, returning -1, 0 or 1.
+//Versions with a suffix come before the same version without one, like pre-releases do.
+func (v *Version) Compare(other *Version) int {
+	for i := 0; i < len(v.Numbers) || i < len(other.Numbers); i++ {
+		a, b := -1, -1
+		if i < len(v.Numbers) {
+			a = v.Numbers[i]
+		}
+		if i < len(other.Numbers) {
+			b = other.Numbers[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.Suffix == other.Suffix:
+		return 0
+	case v.Suffix == "":
+		return 1
+	case other.Suffix == "":
+		return -1
+	case v.Suffix < other.Suffix:
+		return -1
+	default:
+		return 1
+	}
+}
+
+//Constraint is a version range that tags can be resolved with, like ~3.12-slim, ^1.2, >=1.2 <2 or 1.2 - 1.4.
+//Partial versions match whole release lines, so 3.12 matches 3.12.7.
+type Constraint struct {
+	//Any of these have to match, all the comparators of each one have to match
+	alternatives [][]comparator
+	//The variant of the versions, like slim
+	Suffix string
+	//Only match tags with exactly the same variant, otherwise variants can be more specific like slim-bookworm or alpine3.19.
+	//Constraints without a variant always only match versions without one.
+	SameVariant bool
+}
+
+type comparator struct {
+	op      string
+	numbers []int
+}
+
+var comparatorRx = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?\s*(.*)$`)
+
+//ParseConstraint parses a version constraint.
+func ParseConstraint(str string) (*Constraint, error) {
+	c := &Constraint{}
+	suffixSet := false
+	setSuffix := func(suffix string) error {
+		if suffixSet && suffix != c.Suffix {
+			return fmt.Errorf("constraint %s has more than one variant", str)
+		}
+		c.Suffix, suffixSet = suffix, true
+		return nil
+	}
+	for _, alternative := range strings.Split(str, "||") {
+		alternative = strings.TrimSpace(alternative)
+		var comparators []comparator
+		if parts := strings.SplitN(alternative, " - ", 2); len(parts) == 2 {
+			from, err := parsePartialVersion(strings.TrimSpace(parts[0]))
+			if err != nil {
+				return nil, err
+			}
+			to, err := parsePartialVersion(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, err
+			}
+			if err = setSuffix(from.Suffix); err != nil {
+				return nil, err
+			}
+			if err = setSuffix(to.Suffix); err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, comparator{">=", from.Numbers}, comparator{"<", nextVersion(to.Numbers, len(to.Numbers)-1)})
+			c.alternatives = append(c.alternatives, comparators)
+			continue
+		}
+		for _, token := range splitComparators(alternative) {
+			m := comparatorRx.FindStringSubmatch(token)
+			if m[2] == "*" || m[2] == "x" || m[2] == "" {
+				if m[1] != "" {
+					return nil, fmt.Errorf("invalid constraint %s", str)
+				}
+				continue
+			}
+			v, err := parsePartialVersion(m[2])
+			if err != nil {
+				return nil, err
+			}
+			if err = setSuffix(v.Suffix); err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, expandComparator(m[1], v.Numbers)...)
+		}
+		c.alternatives = append(c.alternatives, comparators)
+	}
+	return c, nil
+}
+
+//splitComparators splits a list of comparators by spaces or commas, keeping operators with their versions
+func splitComparators(str string) []string {
+	var output []string
+	pending := ""
+	for _, field := range strings.FieldsFunc(str, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if strings.Trim(field, "<>=~^") == "" {
+			pending += field
+			continue
+		}
+		output = append(output, pending+field)
+		pending = ""
+	}
+	if pending != "" {
+		output = append(output, pending)
+	}
+	return output
+}
+
+//parsePartialVersion parses a version that may end with wildcards, like 1.2.x
+func parsePartialVersion(str string) (*Version, error) {
+	for _, wildcard := range []string{".x", ".X", ".*"} {
+		for strings.Contains(str, wildcard) {
+			str = strings.Replace(str, wildcard, "", 1)
+		}
+	}
+	return ParseVersion(str)
+}
+
+//expandComparator turns tilde, caret and bare versions into ranges
+func expandComparator(op string, numbers []int) []comparator {
+	switch op {
+	case "~":
+		last := len(numbers) - 1
+		if last > 1 {
+			last = 1
+		}
+		return []comparator{{">=", numbers}, {"<", nextVersion(numbers, last)}}
+	case "^":
+		last := 0
+		for last < len(numbers)-1 && numbers[last] == 0 {
+			last++
+		}
+		return []comparator{{">=", numbers}, {"<", nextVersion(numbers, last)}}
+	case "", "=":
+		return []comparator{{">=", numbers}, {"<", nextVersion(numbers, len(numbers)-1)}}
+	}
+	return []comparator{{op, numbers}}
+}
+
+//nextVersion increments the number at the given index and drops the rest, 1.2.3 at 1 becomes 1.3
+func nextVersion(numbers []int, index int) []int {
+	next := append([]int{}, numbers[:index+1]...)
+	next[index]++
+	return next
+}
+
+//compareNumbers compares version numbers, treating missing numbers as zeros
+func compareNumbers(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := 0, 0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (cmp comparator) matches(numbers []int) bool {
+	result := compareNumbers(numbers, cmp.numbers)
+	switch cmp.op {
+	case ">=":
+		return result >= 0
+	case ">":
+		return result > 0
+	case "<=":
+		return result <= 0
+	case "<":
+		return result < 0
+	}
+	return result == 0
+}
+
+//Matches checks if a version satisfies the constraint.
+//Without a variant in the constraint only plain versions match, so ~3.12 doesn't pick 3.12.8-windowsservercore over 3.12.7.
+func (c *Constraint) Matches(v *Version) bool {
+	sameVariant := v.Suffix == c.Suffix
+	if !sameVariant && !c.SameVariant && c.Suffix != "" && strings.HasPrefix(v.Suffix, c.Suffix) {
+		//More specific variants continue after a dash, like slim-bookworm, or with a version, like alpine3.19
+		next := v.Suffix[len(c.Suffix)]
+		sameVariant = next == '-' || next == '.' || (next >= '0' && next <= '9')
+	}
+	if !sameVariant {
+		return false
+	}
+	for _, comparators := range c.alternatives {
+		matched := true
+		for _, cmp := range comparators {
+			if !cmp.matches(v.Numbers) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+//compareCandidates compares two matching versions, preferring the exact variant of the constraint when the numbers are the same,
+//so that ~3.12-slim picks 3.12.7-slim over 3.12.7-slim-bookworm
+func (c *Constraint) compareCandidates(v, other *Version) int {
+	if (&Version{Numbers: v.Numbers}).Compare(&Version{Numbers: other.Numbers}) == 0 {
+		exact, otherExact := v.Suffix == c.Suffix, other.Suffix == c.Suffix
+		if exact && !otherExact {
+			return 1
+		}
+		if otherExact && !exact {
+			return -1
+		}
+	}
+	return v.Compare(other)
+}
+
+//Resolve gets the tag with the highest version that satisfies the constraint, nil if there is none.
+func (tags TagList) Resolve(c *Constraint) *Tag {
+	var best *Tag
+	var bestVersion *Version
+	for i := range tags {
+		v, err := ParseVersion(tags[i].Name)
+		if err != nil || !c.Matches(v) {
+			continue
+		}
+		if best == nil || c.compareCandidates(v, bestVersion) > 0 {
+			best, bestVersion = &tags[i], v
+		}
+	}
+	return best
+}
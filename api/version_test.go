@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Version", func() {
+	var tags api.TagList
+	for _, name := range []string{
+		"latest", "3", "3.11", "3.11.9", "3.12", "3.12.6", "3.12.7", "3.13.0",
+		"3.12-slim", "3.12.7-slim", "3.12.7-slim-bookworm", "3.12.8-slim-bookworm",
+		"1.25.3-alpine3.19", "1.25.4-alpine3.20", "v2", "0.2.5", "0.3.0",
+	} {
+		tags = append(tags, api.Tag{Name: name, Digest: "sha256:" + name})
+	}
+
+	resolve := func(constraint string, sameVariant bool) string {
+		c, err := api.ParseConstraint(constraint)
+		Expect(err).NotTo(HaveOccurred(), constraint)
+		c.SameVariant = sameVariant
+		tag := tags.Resolve(c)
+		if tag == nil {
+			return ""
+		}
+		return tag.Name
+	}
+
+	It("should parse docker style versions", func() {
+		v, err := api.ParseVersion("1.25.3-alpine3.19")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Numbers).To(Equal([]int{1, 25, 3}))
+		Expect(v.Suffix).To(Equal("alpine3.19"))
+		v, err = api.ParseVersion("v2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.String()).To(Equal("2"))
+		_, err = api.ParseVersion("latest")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should resolve constraints", func() {
+		Expect(resolve("~3.12", true)).To(Equal("3.12.7"))
+		Expect(resolve("^3.11", true)).To(Equal("3.13.0"))
+		Expect(resolve("3.11", true)).To(Equal("3.11.9"))
+		Expect(resolve(">=3.11 <3.12.7", true)).To(Equal("3.12.6"))
+		Expect(resolve("3.11 - 3.12", true)).To(Equal("3.12.7"))
+		Expect(resolve("~3.10 || ~3.11", true)).To(Equal("3.11.9"))
+		Expect(resolve("^0.2", true)).To(Equal("0.2.5"))
+		Expect(resolve("2.x", true)).To(Equal("v2"))
+		Expect(resolve("~4", true)).To(Equal(""))
+	})
+
+	It("should stay within variants", func() {
+		Expect(resolve("~3.12-slim", false)).To(Equal("3.12.8-slim-bookworm"))
+		Expect(resolve("~3.12-slim", true)).To(Equal("3.12.7-slim"))
+		Expect(resolve("~1.25-alpine3.19", true)).To(Equal("1.25.3-alpine3.19"))
+		Expect(resolve("~1.25-alpine", false)).To(Equal("1.25.4-alpine3.20"))
+		Expect(resolve("~1.25-alpine", true)).To(Equal(""))
+		Expect(resolve("~1.25-alp", false)).To(Equal(""))
+		Expect(resolve("~1.25", false)).To(Equal(""))
+		Expect(resolve("~1.25", true)).To(Equal(""))
+	})
+
+	It("should only match plain versions without a variant", func() {
+		Expect(resolve("~3.12", false)).To(Equal("3.12.7"))
+		windows := api.TagList{{Name: "3.12.7"}, {Name: "3.12.8-windowsservercore"}, {Name: "3.12.8-slim-bookworm"}}
+		c, err := api.ParseConstraint("~3.12")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(windows.Resolve(c).Name).To(Equal("3.12.7"))
+	})
+
+	It("should prefer the exact variant of the same version", func() {
+		c, err := api.ParseConstraint("~3.12-slim")
+		Expect(err).NotTo(HaveOccurred())
+		for _, names := range [][]string{{"3.12.9-slim-bookworm", "3.12.9-slim"}, {"3.12.9-slim", "3.12.9-slim-bookworm"}} {
+			variants := api.TagList{{Name: "3.12.8-slim"}, {Name: names[0]}, {Name: names[1]}}
+			Expect(variants.Resolve(c).Name).To(Equal("3.12.9-slim"))
+		}
+	})
+
+	It("should reject invalid constraints", func() {
+		for _, c := range []string{"~latest", ">=1-slim <2-alpine", ">="} {
+			_, err := api.ParseConstraint(c)
+			Expect(err).To(HaveOccurred(), c)
+		}
+	})
+})
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+//ErrWaitTimeout is returned when a tag didn't reach the wanted state in time.
+var ErrWaitTimeout = errors.New("timed out")
+
+//TagCondition is the state to wait for a tag to reach. With no digests set, the tag only has to exist.
+type TagCondition struct {
+	//The tag has to point to this digest
+	Digest string
+	//The tag has to point to any digest other than this one
+	ChangedFrom string
+}
+
+//Satisfied checks if a tag that points to the given digest satisfies the condition.
+func (c *TagCondition) Satisfied(digest string) bool {
+	if c.Digest != "" && digest != c.Digest {
+		return false
+	}
+	return c.ChangedFrom == "" || digest != c.ChangedFrom
+}
+
+//WaitForTag polls a tag until it satisfies the condition, or returns ErrWaitTimeout.
+//Polling starts at the given interval and backs off up to four times it while the tag doesn't change.
+func (rc *RegistryClient) WaitForTag(repo, tag string, condition TagCondition, timeout, interval time.Duration) (*Descriptor, error) {
+	deadline := time.Now().Add(timeout)
+	wait := interval
+	for {
+		desc, err := rc.HeadManifest(repo, tag)
+		if err == nil && condition.Satisfied(desc.Digest) {
+			return desc, nil
+		}
+		if err != nil && !IsNotFound(err) && !isTransient(err) {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrWaitTimeout
+		}
+		if wait > remaining {
+			wait = remaining
+		}
+		time.Sleep(wait)
+		wait = wait * 3 / 2
+		if wait > 4*interval {
+			wait = 4 * interval
+		}
+	}
+}
+
+//isTransient checks if an error may go away when retrying, like network timeouts, rate limits or server errors.
+//Errors that won't change by waiting, like unknown hosts, refused connections or bad authentication, are not transient.
+func isTransient(err error) bool {
+	switch e := err.(type) {
+	case *RegistryError:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	case net.Error:
+		return e.Timeout() || e.Temporary()
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("WaitForTag", func() {
+	var registry *fakeRegistry
+	var client *api.RegistryClient
+	first := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","layers":[]}`)
+	second := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","layers":[{}]}`)
+
+	BeforeEach(func() {
+		registry = newFakeRegistry("")
+		client = api.NewRegistryClient(registry.server.URL, "", "")
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	It("should wait for a tag to appear", func() {
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			registry.addManifest("user/repo", "1.0", api.MediaTypeDockerManifest, first)
+		}()
+		desc, err := client.WaitForTag("user/repo", "1.0", api.TagCondition{}, time.Second, 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.Digest).To(Equal(digestOf(first)))
+	})
+
+	It("should wait for a tag to change", func() {
+		old := registry.addManifest("user/repo", "latest", api.MediaTypeDockerManifest, first)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			registry.addManifest("user/repo", "latest", api.MediaTypeDockerManifest, second)
+		}()
+		desc, err := client.WaitForTag("user/repo", "latest", api.TagCondition{ChangedFrom: old}, time.Second, 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.Digest).To(Equal(digestOf(second)))
+	})
+
+	It("should time out", func() {
+		registry.addManifest("user/repo", "latest", api.MediaTypeDockerManifest, first)
+		start := time.Now()
+		_, err := client.WaitForTag("user/repo", "latest", api.TagCondition{Digest: digestOf(second)}, 50*time.Millisecond, 10*time.Millisecond)
+		Expect(err).To(Equal(api.ErrWaitTimeout))
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+	})
+
+	It("should fail right away when the registry can't be reached", func() {
+		registry.server.Close()
+		start := time.Now()
+		_, err := client.WaitForTag("user/repo", "latest", api.TagCondition{}, 5*time.Second, 10*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(Equal(api.ErrWaitTimeout))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	TagCreated = "created"
+	TagDeleted = "deleted"
+	TagChanged = "changed"
+)
+
+//TagEvent is a change of a tag that was noticed while watching a repository.
+type TagEvent struct {
+	//created, deleted or changed
+	Type       string `json:"type"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest,omitempty"`
+	//The digest the tag pointed to before it changed or was deleted
+	PreviousDigest string    `json:"previous_digest,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+//TagSnapshot is the digest of each tag of a repository at some point.
+type TagSnapshot map[string]string
+
+//SnapshotTags gets the digest of each tag.
+func SnapshotTags(tags TagList) TagSnapshot {
+	snapshot := make(TagSnapshot)
+	for _, t := range tags {
+		snapshot[t.Name] = t.Digest
+	}
+	return snapshot
+}
+
+//DiffSnapshots gets the events that turn one snapshot of a repository into another, sorted by tag.
+func DiffSnapshots(repository string, from, to TagSnapshot, now time.Time) []TagEvent {
+	var events []TagEvent
+	for tag, digest := range to {
+		previous, ok := from[tag]
+		switch {
+		case !ok:
+			events = append(events, TagEvent{Type: TagCreated, Repository: repository, Tag: tag, Digest: digest, Time: now})
+		case previous != digest:
+			events = append(events, TagEvent{Type: TagChanged, Repository: repository, Tag: tag, Digest: digest, PreviousDigest: previous, Time: now})
+		}
+	}
+	for tag, digest := range from {
+		if _, ok := to[tag]; !ok {
+			events = append(events, TagEvent{Type: TagDeleted, Repository: repository, Tag: tag, PreviousDigest: digest, Time: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Tag < events[j].Tag
+	})
+	return events
+}
+
+//PendingEvent is an event that some sinks, like a command or an url, failed to get.
+type PendingEvent struct {
+	TagEvent
+	//The sinks that still have to get the event
+	Sinks []string `json:"sinks"`
+}
+
+//WatchState is the last snapshot of each watched repository, so that events aren't repeated after a restart.
+type WatchState struct {
+	Repositories map[string]TagSnapshot `json:"repositories"`
+	//The events of each repository that have to be sent again to some of the sinks
+	Pending map[string][]PendingEvent `json:"pending,omitempty"`
+}
+
+//SetPending replaces the pending events of a repository.
+func (s *WatchState) SetPending(repository string, events []PendingEvent) {
+	if len(events) == 0 {
+		delete(s.Pending, repository)
+		return
+	}
+	if s.Pending == nil {
+		s.Pending = make(map[string][]PendingEvent)
+	}
+	s.Pending[repository] = events
+}
+
+//LoadWatchState reads the watch state from a file, a missing file is an empty state.
+func LoadWatchState(path string) (*WatchState, error) {
+	state := &WatchState{Repositories: make(map[string]TagSnapshot)}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, state)
+	if err != nil {
+		return nil, err
+	}
+	if state.Repositories == nil {
+		state.Repositories = make(map[string]TagSnapshot)
+	}
+	return state, nil
+}
+
+//Save writes the watch state to a file, replacing it atomically.
+func (s *WatchState) Save(path string) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, content, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sp0x/docker-hub-cli/api"
+)
+
+var _ = Describe("Watch", func() {
+	now := time.Now()
+
+	It("should find tag events", func() {
+		from := api.SnapshotTags(api.TagList{{Name: "1.0", Digest: "sha256:a"}, {Name: "latest", Digest: "sha256:a"}, {Name: "old", Digest: "sha256:o"}})
+		to := api.SnapshotTags(api.TagList{{Name: "1.0", Digest: "sha256:a"}, {Name: "latest", Digest: "sha256:b"}, {Name: "1.1", Digest: "sha256:b"}})
+		Expect(api.DiffSnapshots("user/repo", from, to, now)).To(Equal([]api.TagEvent{
+			{Type: api.TagCreated, Repository: "user/repo", Tag: "1.1", Digest: "sha256:b", Time: now},
+			{Type: api.TagChanged, Repository: "user/repo", Tag: "latest", Digest: "sha256:b", PreviousDigest: "sha256:a", Time: now},
+			{Type: api.TagDeleted, Repository: "user/repo", Tag: "old", PreviousDigest: "sha256:o", Time: now},
+		}))
+		Expect(api.DiffSnapshots("user/repo", to, to, now)).To(BeEmpty())
+	})
+
+	It("should persist the state", func() {
+		dir, err := ioutil.TempDir("", "watch")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "state", "watch.json")
+		state, err := api.LoadWatchState(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Repositories).To(BeEmpty())
+		state.Repositories["user/repo"] = api.TagSnapshot{"latest": "sha256:a"}
+		event := api.TagEvent{Type: api.TagCreated, Repository: "user/repo", Tag: "latest", Digest: "sha256:a", Time: time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)}
+		state.SetPending("user/repo", []api.PendingEvent{{TagEvent: event, Sinks: []string{"post"}}})
+		Expect(state.Save(path)).To(Succeed())
+		loaded, err := api.LoadWatchState(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(state))
+		loaded.SetPending("user/repo", nil)
+		Expect(loaded.Pending).To(BeEmpty())
+	})
+})
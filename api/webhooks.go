@@ -33,6 +33,17 @@ func (wh *Webhook) GetWebhookUrl() *string {
 	return wh.HookUrl
 }
 
+//GetWebhookUrls gets all the urls that a webhook calls
+func (wh *Webhook) GetWebhookUrls() []WebhookUrl {
+	if len(wh.Hooks) > 0 {
+		return wh.Hooks
+	}
+	if wh.HookUrl != nil {
+		return []WebhookUrl{{Name: wh.Name, Url: *wh.HookUrl}}
+	}
+	return nil
+}
+
 //DeleteAllWebhooks deletes all webhooks for a given repository
 func (d *DockerApi) DeleteAllWebhooks(username, name string) error {
 	hooks, err := d.GetWebhooks(username, name, 0, 0)
@@ -63,22 +74,38 @@ func (d *DockerApi) DeleteWebhook(username, name, webhookName string) error {
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/webhook_pipeline/%s/", username, name, webhookName)) + "/"
 	r, err := requests.Delete(d.client, pth, d.token)
 	if err != nil {
-		if r != nil {
-			return fmt.Errorf(parseError(r))
-		} else {
-			return err
-		}
+		return hubError(err, r)
 	}
 	return nil
 }
 
 //GetWebhooks Gets the webhooks for a repository you own.
 func (d *DockerApi) GetWebhooks(username, name string, pageSize, page int) ([]Webhook, error) {
+	webhooks, _, err := d.getWebhooksPage(username, name, pageSize, page)
+	return webhooks, err
+}
+
+//GetAllWebhooks gets all the webhooks of a repository you own, going through all the pages
+func (d *DockerApi) GetAllWebhooks(username, name string) ([]Webhook, error) {
+	var output []Webhook
+	for page := 1; ; page++ {
+		webhooks, hasNext, err := d.getWebhooksPage(username, name, 100, page)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, webhooks...)
+		if !hasNext {
+			return output, nil
+		}
+	}
+}
+
+func (d *DockerApi) getWebhooksPage(username, name string, pageSize, page int) ([]Webhook, bool, error) {
 	if username == "" || username == "_" {
 		username = "library"
 	}
 	if name == "" {
-		return nil, fmt.Errorf("no image name given")
+		return nil, false, fmt.Errorf("no image name given")
 	}
 	username = strings.ToLower(username)
 	name = strings.ToLower(name)
@@ -91,23 +118,30 @@ func (d *DockerApi) GetWebhooks(username, name string, pageSize, page int) ([]We
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/webhook_pipeline?page_size=%v&page=%v", username, name, pageSize, page))
 	r, err := requests.Get(d.client, pth, d.token)
 	if err != nil {
-		return nil, err
+		return nil, false, hubError(err, r)
 	}
 	var searchRes SearchResult
 	err = json.Unmarshal(r, &searchRes)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	var webhooks []Webhook
-	err = json.Unmarshal(searchRes.Results, &webhooks)
-	if err != nil {
-		return nil, err
+	if searchRes.Results != nil {
+		err = json.Unmarshal(searchRes.Results, &webhooks)
+		if err != nil {
+			return nil, false, err
+		}
 	}
-	return webhooks, nil
+	return webhooks, searchRes.Next != nil, nil
 }
 
 //CreateWebhook Creates a webhook for the given username and repository.
 func (d *DockerApi) CreateWebhook(username, name, webhookName string, url string) (*Webhook, error) {
+	return d.CreateWebhookUrls(username, name, webhookName, []WebhookUrl{{Name: webhookName, Url: url}})
+}
+
+//CreateWebhookUrls Creates a webhook that calls all the given urls.
+func (d *DockerApi) CreateWebhookUrls(username, name, webhookName string, urls []WebhookUrl) (*Webhook, error) {
 	if username == "" {
 		return nil, fmt.Errorf("no user given")
 	}
@@ -117,24 +151,28 @@ func (d *DockerApi) CreateWebhook(username, name, webhookName string, url string
 	if webhookName == "" {
 		return nil, fmt.Errorf("no webhookName given")
 	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no webhook url given")
+	}
 	username = strings.ToLower(username)
 	pth := d.getRoute(fmt.Sprintf("repositories/%s/%s/webhook_pipeline/", username, name)) + "/"
+	var hooks []map[string]string
+	for _, u := range urls {
+		hookName := u.Name
+		if hookName == "" {
+			hookName = webhookName
+		}
+		hooks = append(hooks, map[string]string{"name": hookName, "hook_url": u.Url})
+	}
 	data := map[string]interface{}{
 		"name":                  webhookName,
 		"expect_final_callback": false,
-		"webhooks": []map[string]string{
-			{
-				"name": webhookName, "hook_url": url,
-			}},
-		"registry": "registry-1.docker.io",
+		"webhooks":              hooks,
+		"registry":              "registry-1.docker.io",
 	}
 	r, err := requests.Post(d.client, pth, data, d.token)
 	if err != nil {
-		if r != nil {
-			return nil, fmt.Errorf(parseError(r))
-		} else {
-			return nil, err
-		}
+		return nil, hubError(err, r)
 	}
 	var hook Webhook
 	err = json.Unmarshal(r, &hook)
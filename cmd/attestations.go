@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"text/tabwriter"
+)
+
+var attestationPlatform string
+var attestationJson bool
+
+func init() {
+	imageArgs := func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.New("only one image accepted")
+		} else if len(args) < 1 {
+			return errors.New("image is missing")
+		}
+		return nil
+	}
+	sbomCmd := &cobra.Command{
+		Use:   "sbom [username/repo:tag]",
+		Short: "Show the packages from the SBOM attestation of an image",
+		Long:  "Reads the SPDX SBOM that buildkit attached to the image index, for a single platform.",
+		Args:  imageArgs,
+		Run:   sbomCommand,
+	}
+	provenanceCmd := &cobra.Command{
+		Use:   "provenance [username/repo:tag]",
+		Short: "Show the build provenance attestation of an image",
+		Long:  "Reads the SLSA provenance that buildkit attached to the image index, for a single platform.",
+		Args:  imageArgs,
+		Run:   provenanceCommand,
+	}
+	for _, c := range []*cobra.Command{sbomCmd, provenanceCmd} {
+		c.Flags().StringVar(&attestationPlatform, "platform", "linux/amd64", "The platform of the image")
+		c.Flags().BoolVar(&attestationJson, "json", false, "Output as json")
+		rootCmd.AddCommand(c)
+	}
+}
+
+//getAttestationTarget parses the image and platform that attestations are requested for
+func getAttestationTarget(arg string) (*api.ImageReference, *api.Platform) {
+	ref, err := api.ParseReference(arg)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	platform, err := api.ParsePlatform(attestationPlatform)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	return ref, platform
+}
+
+func sbomCommand(cmd *cobra.Command, args []string) {
+	ref, platform := getAttestationTarget(args[0])
+	packages, err := getRegistryClient(ref).GetSBOM(ref.Repository(), ref.Reference(), platform)
+	if err != nil {
+		fmt.Printf("Could not get the SBOM of %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	if attestationJson {
+		output, _ := json.MarshalIndent(packages, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tVERSION\tLICENSE\tPURL")
+	for _, p := range packages {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Version, p.License, p.PURL)
+	}
+	_ = w.Flush()
+}
+
+func provenanceCommand(cmd *cobra.Command, args []string) {
+	ref, platform := getAttestationTarget(args[0])
+	provenance, err := getRegistryClient(ref).GetProvenance(ref.Repository(), ref.Reference(), platform)
+	if err != nil {
+		fmt.Printf("Could not get the provenance of %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	if attestationJson {
+		output, _ := json.MarshalIndent(provenance, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	fmt.Printf("Builder: %s\n", provenance.BuilderId)
+	fmt.Printf("Build type: %s\n", provenance.BuildType)
+	if provenance.StartedOn != nil && provenance.FinishedOn != nil {
+		fmt.Printf("Built: %s, took %s\n", provenance.FinishedOn, provenance.FinishedOn.Sub(*provenance.StartedOn))
+	}
+	fmt.Println("Materials:")
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, m := range provenance.Materials {
+		digest := ""
+		if sha, ok := m.Digest["sha256"]; ok {
+			digest = "sha256:" + sha
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", m.URI, digest)
+	}
+	_ = w.Flush()
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
 	"github.com/sp0x/docker-hub-cli/api"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 var configFile string
@@ -46,6 +53,111 @@ func getAvailableDockerApi() *api.DockerApi {
 	}
 }
 
+//getRegistryClient gets a client for the registry of the given image, authenticated with the credentials of the docker config file if it has any.
+func getRegistryClient(ref *api.ImageReference) *api.RegistryClient {
+	username, password := getDockerCredentials(ref.Registry)
+	return api.NewRegistryClient(getRegistryHost(ref), username, password)
+}
+
+//getPushRegistryClient gets a client for changing the registry of the given image, which needs credentials.
+//Without credentials in the docker config file, the logged in docker hub user is asked for a password or personal access token.
+//The password is only used for this command and isn't saved.
+func getPushRegistryClient(ref *api.ImageReference) *api.RegistryClient {
+	username, password := getDockerCredentials(ref.Registry)
+	if username == "" && ref.Registry == "" && terminal.IsTerminal(int(os.Stdin.Fd())) {
+		authCfg, _ := getAuthConfig()
+		if authCfg != nil && authCfg.Username != "" {
+			fmt.Printf("Password or personal access token for %s: ", authCfg.Username)
+			secret, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			username, password = authCfg.Username, string(secret)
+		}
+	}
+	if username == "" || password == "" {
+		host := ref.Registry
+		if host == "" {
+			host = "docker hub"
+		}
+		fmt.Printf("Login required to change %s, use `docker login` to store credentials for %s.\n", ref, host)
+		os.Exit(1)
+	}
+	return api.NewRegistryClient(getRegistryHost(ref), username, password)
+}
+
+func getRegistryHost(ref *api.ImageReference) string {
+	if ref.Registry == "" {
+		return viper.GetString("docker.registry")
+	}
+	return ref.Registry
+}
+
+//getDockerCredentials gets the credentials that `docker login` stored for a registry, empty for docker hub.
+//Credentials kept in a credential helper can't be read.
+func getDockerCredentials(registry string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	var dockerCfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err = json.Unmarshal(content, &dockerCfg); err != nil {
+		log.Warningf("Could not read the docker config file: %v", err)
+		return "", ""
+	}
+	for key, entry := range dockerCfg.Auths {
+		if dockerConfigHost(key) != registry {
+			continue
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				continue
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				return parts[0], parts[1]
+			}
+		}
+		if entry.Username != "" {
+			return entry.Username, entry.Password
+		}
+	}
+	return "", ""
+}
+
+//dockerConfigHost gets the registry host of a docker config entry, empty for docker hub.
+func dockerConfigHost(key string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+	switch host {
+	case "index.docker.io", "docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return ""
+	}
+	return host
+}
+
+//getDataPath gets the path of a file that the cli keeps its own data in, under ~/.docker-hub-cli.d
+func getDataPath(name string) string {
+	home, err := homedir.Dir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".docker-hub-cli.d", name)
+}
+
 func initConfig() {
 	if configFile != "" {
 		viper.SetConfigFile(configFile)
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
 	"math"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -80,3 +85,141 @@ func timeElapsed(now time.Time, then time.Time, full bool) string {
 	}
 	return parts[0] + text
 }
+
+//formatSize formats a size in bytes into a human readable string
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	i := 0
+	for math.Abs(value) >= 1000 && i < len(units)-1 {
+		value /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", size, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[i])
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+//plural picks the singular or plural suffix for a count
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+//truncate shortens a string to a maximum number of characters, ending it with ... if it was cut
+func truncate(str string, max int) string {
+	str = strings.Join(strings.Fields(str), " ")
+	runes := []rune(str)
+	if len(runes) <= max {
+		return str
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+//parseTime parses a date like 2020-01-31, a RFC3339 time, or an age like 30d which is counted back from now
+func parseTime(str string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", str); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	age, err := api.ParseAge(str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, use a date like 2020-01-31 or an age like 30d", str)
+	}
+	return time.Now().Add(-age), nil
+}
+
+//readLine asks a question and reads the answer from the terminal
+func readLine(question string) string {
+	fmt.Print(question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+//confirm asks a yes or no question, anything but yes is a no
+func confirm(question string) bool {
+	answer := strings.ToLower(readLine(question + " [y/N] "))
+	return answer == "y" || answer == "yes"
+}
+
+//listFilter is the --filter expression of the list commands
+var listFilter string
+var parsedListFilter *api.Filter
+
+//addFilterFlag adds the --filter flag to a command that filters values like the empty target.
+//The filter is parsed and checked against the target before the command runs, so typos fail even if nothing is listed.
+func addFilterFlag(cmd *cobra.Command, fields string, target api.Filterable) {
+	cmd.Flags().StringVar(&listFilter, "filter", "", "Only list what matches this expression, like \"size > 200MB && updated < 30d\". "+
+		"Ages like 30d compare how long ago a time was, dates like \"2020-01-31\" compare the time itself. Fields: "+fields)
+	//The command's own pre run goes first, so it can check the filter against a target that depends on the arguments
+	if preRunE := cmd.PreRunE; preRunE != nil {
+		cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+			if err := preRunE(cmd, args); err != nil {
+				return err
+			}
+			parseListFilter(target)
+			return nil
+		}
+		return
+	}
+	preRun := cmd.PreRun
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+		parseListFilter(target)
+	}
+}
+
+//parseListFilter parses the --filter expression and checks it against the target, exiting if it's invalid
+func parseListFilter(target api.Filterable) {
+	if listFilter == "" || parsedListFilter != nil {
+		return
+	}
+	filter, err := api.ParseFilter(listFilter)
+	if err == nil && target != nil {
+		err = filter.Validate(target)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	parsedListFilter = filter
+}
+
+//matchesListFilter checks if the target matches the --filter expression, exiting if the filter is invalid
+func matchesListFilter(target api.Filterable) bool {
+	if listFilter == "" {
+		return true
+	}
+	parseListFilter(nil)
+	matched, err := parsedListFilter.Match(target)
+	if err != nil {
+		fmt.Printf("Invalid filter %s: %v\n", listFilter, err)
+		os.Exit(1)
+	}
+	return matched
+}
+
+//filterTagList gets the tags that match the --filter expression
+func filterTagList(tags api.TagList) api.TagList {
+	var output api.TagList
+	for _, t := range tags {
+		if matchesListFilter(t) {
+			output = append(output, t)
+		}
+	}
+	return output
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var imageSavePlatform string
+var imageSaveOutput string
+var imageSaveFormat string
+var imagePushChunkSize int64
+var imagePushRef string
+
+var imageCmd = &cobra.Command{
+	Use:   "image",
+	Short: "Transfer images between the registry and local files",
+}
+
+func init() {
+	saveImageCmd := &cobra.Command{
+		Use:   "save [username/repo:tag]",
+		Short: "Save an image to an OCI image layout, without a docker daemon",
+		Long: "Downloads the manifests, config and layers of an image into an OCI image layout directory, or into a tarball if the output ends with .tar.\n" +
+			"Each blob is verified while it's downloaded, and interrupted downloads are resumed when saving again.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: saveImageCommand,
+	}
+	saveImageCmd.Flags().StringVar(&imageSavePlatform, "platform", "", "Only save the image for this platform, all platforms are saved by default")
+	saveImageCmd.Flags().StringVarP(&imageSaveOutput, "output", "o", "", "The directory or .tar file to save the image to")
+	saveImageCmd.Flags().StringVar(&imageSaveFormat, "format", "oci", "The format of the image, oci or docker. Docker archives can be loaded with `docker load` and default to linux/amd64")
+	_ = saveImageCmd.MarkFlagRequired("output")
+
+	pushImageCmd := &cobra.Command{
+		Use:   "push [directory or file.tar] [username/repo:tag]",
+		Short: "Push an OCI image layout to a repository",
+		Long: "Uploads the blobs of an OCI image layout, skipping the ones the registry already has, and then pushes the manifest or index.\n" +
+			"A layout with a single image is pushed whatever its ref name is. If it contains multiple images then the one named by --ref, or with the same ref name as the target tag, is pushed.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("an image layout and a target image are required")
+			}
+			return nil
+		},
+		Run: pushImageCommand,
+	}
+	pushImageCmd.Flags().StringVar(&imagePushRef, "ref", "", "The ref name of the manifest to push when the layout has several, the target tag by default")
+	pushImageCmd.Flags().Int64Var(&imagePushChunkSize, "chunk-size", api.DefaultChunkSize, "Blobs bigger than this many bytes are uploaded in chunks")
+	imageCmd.AddCommand(saveImageCmd)
+	imageCmd.AddCommand(pushImageCmd)
+	rootCmd.AddCommand(imageCmd)
+}
+
+func saveImageCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if imageSaveFormat != "oci" && imageSaveFormat != "docker" {
+		fmt.Printf("Unsupported format: %s\n", imageSaveFormat)
+		os.Exit(1)
+	}
+	if imageSaveFormat == "docker" && imageSavePlatform == "" {
+		imageSavePlatform = "linux/amd64"
+	}
+	var platform *api.Platform
+	if imageSavePlatform != "" {
+		platform, err = api.ParsePlatform(imageSavePlatform)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+	isTar := strings.HasSuffix(imageSaveOutput, ".tar")
+	layoutDir := imageSaveOutput
+	if isTar {
+		//The layout is kept next to the tarball until it's complete, so that downloads can be resumed.
+		layoutDir = imageSaveOutput + ".download"
+	}
+	layout, err := api.NewImageLayout(layoutDir)
+	if err != nil {
+		fmt.Printf("Could not create image layout: %v\n", err)
+		os.Exit(1)
+	}
+	manifest, err := getRegistryClient(ref).SaveImage(ref.Repository(), ref.Reference(), platform, layout, ref.Tag)
+	if err != nil {
+		fmt.Printf("Could not save %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	if imageSaveFormat == "docker" {
+		parsed, err := manifest.Parse()
+		if err == nil {
+			repoTag := ""
+			if ref.Tag != "" {
+				repoTag = ref.WithTag(ref.Tag).String()
+			}
+			err = layout.AddDockerManifest(repoTag, parsed)
+		}
+		if err != nil {
+			fmt.Printf("Could not create docker archive: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if isTar {
+		err = writeLayoutTar(layout, imageSaveOutput)
+		if err != nil {
+			fmt.Printf("Could not write %s: %v\n", imageSaveOutput, err)
+			os.Exit(1)
+		}
+		_ = os.RemoveAll(layoutDir)
+	}
+	fmt.Printf("Saved %s@%s to %s\n", ref.WithTag(ref.Tag), manifest.Digest, imageSaveOutput)
+}
+
+func writeLayoutTar(layout *api.ImageLayout, output string) error {
+	tmp := output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = layout.WriteTar(f)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, output)
+}
+
+func pushImageCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[1])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Digest != "" {
+		fmt.Printf("Images can only be pushed to a tag\n")
+		os.Exit(1)
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	var layout *api.ImageLayout
+	var tmpDir string
+	if strings.HasSuffix(args[0], ".tar") {
+		tmpDir, err = ioutil.TempDir("", "docker-hub-cli")
+		if err == nil {
+			layout, err = api.ExtractLayoutTar(args[0], tmpDir)
+		}
+	} else {
+		layout = &api.ImageLayout{Path: args[0]}
+	}
+	var desc *api.Descriptor
+	if err == nil {
+		desc, err = layout.SelectManifest(imagePushRef, ref.Tag)
+	}
+	if err == nil {
+		registry := getPushRegistryClient(ref)
+		registry.ChunkSize = imagePushChunkSize
+		err = registry.PushImage(layout, *desc, ref.Repository(), ref.Tag)
+	}
+	if tmpDir != "" {
+		_ = os.RemoveAll(tmpDir)
+	}
+	if err != nil {
+		fmt.Printf("Could not push %s to %s: %v\n", args[0], ref, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Pushed %s@%s\n", ref, desc.Digest)
+}
@@ -53,6 +53,8 @@ func init() {
 			authCfg.Token = dockerApi.GetToken()
 			viper.Set("auth", authCfg)
 			_ = viper.WriteConfig()
+			//The config keeps the hub token, so only the user may read it
+			_ = os.Chmod(viper.ConfigFileUsed(), 0600)
 			fmt.Printf("Logged in.")
 		},
 	})
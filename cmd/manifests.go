@@ -0,0 +1,328 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var manifestFrom []string
+var manifestAnnotate []string
+var manifestUntaggedDelete bool
+var manifestUntaggedYes bool
+var manifestUntaggedThreshold int
+var manifestRmYes bool
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Manage manifests and multi platform images",
+}
+
+func init() {
+	createManifestCmd := &cobra.Command{
+		Use:   "create [username/repo:tag]",
+		Short: "Create a multi platform image from single platform images",
+		Long: "Assembles a manifest list from existing single platform images and pushes it under the given tag.\n" +
+			"The platform of each image is read from its config and can be changed with --annotate, for example\n" +
+			"  manifest create user/repo:1.0 --from user/repo:1.0-amd64 --from user/repo:1.0-arm64 --annotate user/repo:1.0-arm64=variant=v8",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: createManifestCommand,
+	}
+	createManifestCmd.Flags().StringArrayVar(&manifestFrom, "from", nil, "A single platform image to add, can be repeated")
+	createManifestCmd.Flags().StringArrayVar(&manifestAnnotate, "annotate", nil, "Set the variant, os.version or os.features of an image, as image=field=value")
+	_ = createManifestCmd.MarkFlagRequired("from")
+	untaggedManifestCmd := &cobra.Command{
+		Use:   "untagged [username/repo]",
+		Short: "List the manifests of a repository that no tag points to",
+		Long: "Lists the manifests that are left behind in a docker hub repository when their tags are moved or deleted.\n" +
+			"With --delete you're asked to confirm, if more manifests than the threshold are listed then nothing is deleted unless --yes is given.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a repository is required")
+			}
+			return nil
+		},
+		Run: untaggedManifestsCommand,
+	}
+	untaggedManifestCmd.Flags().BoolVar(&manifestUntaggedDelete, "delete", false, "Delete all the untagged manifests")
+	untaggedManifestCmd.Flags().BoolVarP(&manifestUntaggedYes, "yes", "y", false, "Delete without asking for confirmation")
+	untaggedManifestCmd.Flags().IntVar(&manifestUntaggedThreshold, "threshold", 10, "When more manifests than this are listed, only list them unless --yes is given")
+	rmManifestCmd := &cobra.Command{
+		Use:   "rm [username/repo@digest...]",
+		Short: "Delete manifests by digest",
+		Long: "Deletes manifests together with all the tags that point to them.\n" +
+			"Docker hub warns before deleting manifests that are still tagged or were pulled recently, " +
+			"the warnings are listed and you're asked to confirm unless --yes is given.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("at least one manifest is required")
+			}
+			return nil
+		},
+		Run: rmManifestCommand,
+	}
+	rmManifestCmd.Flags().BoolVarP(&manifestRmYes, "yes", "y", false, "Delete manifests that docker hub warns about without asking for confirmation")
+	manifestCmd.AddCommand(createManifestCmd)
+	addFilterFlag(untaggedManifestCmd, "digest, tags, pushed, pulled, status", api.HubImage{})
+	manifestCmd.AddCommand(untaggedManifestCmd)
+	manifestCmd.AddCommand(rmManifestCmd)
+	rootCmd.AddCommand(manifestCmd)
+}
+
+func createManifestCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Digest != "" {
+		fmt.Printf("Images can only be pushed to a tag\n")
+		os.Exit(1)
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	var entries []api.IndexEntry
+	for _, from := range manifestFrom {
+		source, err := api.ParseReference(from)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		if source.Registry != ref.Registry {
+			fmt.Printf("%s is not in the same registry as %s\n", from, ref)
+			os.Exit(1)
+		}
+		entries = append(entries, api.IndexEntry{Repository: source.Repository(), Reference: source.Reference()})
+	}
+	for _, annotation := range manifestAnnotate {
+		parts := strings.SplitN(annotation, "=", 3)
+		if len(parts) != 3 {
+			fmt.Printf("Invalid annotation %s, expected image=field=value\n", annotation)
+			os.Exit(1)
+		}
+		index := -1
+		for i, from := range manifestFrom {
+			if from == parts[0] {
+				index = i
+			}
+		}
+		if index < 0 {
+			fmt.Printf("%s is not one of the images given with --from\n", parts[0])
+			os.Exit(1)
+		}
+		err = entries[index].Annotate(parts[1], parts[2])
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+	manifest, err := getPushRegistryClient(ref).CreateIndex(ref.Repository(), ref.Tag, entries)
+	if err != nil {
+		fmt.Printf("Could not create %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	parsed, _ := manifest.Parse()
+	fmt.Printf("Pushed %s@%s\n", ref, manifest.Digest)
+	for i, m := range parsed.Manifests {
+		platform := m.Platform.String()
+		if m.Platform.OSVersion != "" {
+			platform += " " + m.Platform.OSVersion
+		}
+		fmt.Printf("  %s %s (%s)\n", platform, m.Digest, manifestFrom[i])
+	}
+}
+
+func untaggedManifestsCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Registry != "" {
+		fmt.Printf("Untagged manifests can only be listed for docker hub repositories\n")
+		os.Exit(1)
+	}
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	images, err := dapi.GetUntaggedImages(ref.Namespace, ref.Name)
+	if err != nil {
+		fmt.Printf("Could not list the manifests of %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	if len(images) == 0 {
+		fmt.Printf("%s has no untagged manifests\n", ref.Repository())
+		return
+	}
+	var digests []string
+	for _, image := range images {
+		if !matchesListFilter(image) {
+			continue
+		}
+		var tags []string
+		for _, t := range image.Tags {
+			tags = append(tags, t.Tag)
+		}
+		line := image.Digest
+		if image.LastPushed != nil {
+			line += " pushed " + timeElapsedRightNow(*image.LastPushed, false)
+		}
+		if len(tags) > 0 {
+			line += ", was " + strings.Join(tags, ", ")
+		}
+		fmt.Println(line)
+		digests = append(digests, image.Digest)
+	}
+	if !manifestUntaggedDelete || len(digests) == 0 {
+		return
+	}
+	if !manifestUntaggedYes {
+		if len(digests) > manifestUntaggedThreshold {
+			fmt.Printf("More than %d manifests are untagged, run again with --yes to delete them\n", manifestUntaggedThreshold)
+			return
+		}
+		if !confirm(fmt.Sprintf("Delete these %d %s?", len(digests), plural(len(digests), "manifest", "manifests"))) {
+			return
+		}
+	}
+	if !deleteHubManifests(dapi, ref, digests, manifestUntaggedYes) {
+		os.Exit(1)
+	}
+}
+
+func rmManifestCommand(cmd *cobra.Command, args []string) {
+	var refs []*api.ImageReference
+	for _, arg := range args {
+		ref, err := api.ParseReference(arg)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		if ref.Digest == "" {
+			fmt.Printf("%s has no digest, manifests can only be deleted by digest\n", arg)
+			os.Exit(1)
+		}
+		refs = append(refs, ref)
+	}
+	failed := false
+	//Docker hub deletes all the manifests of a repository with a single request
+	hubDigests := make(map[string][]string)
+	var hubRepos []*api.ImageReference
+	for _, ref := range refs {
+		if ref.Registry == "" {
+			repo := ref.Repository()
+			if _, ok := hubDigests[repo]; !ok {
+				hubRepos = append(hubRepos, ref)
+			}
+			hubDigests[repo] = append(hubDigests[repo], ref.Digest)
+			continue
+		}
+		err := getPushRegistryClient(ref).DeleteManifest(ref.Repository(), ref.Digest)
+		if err != nil {
+			fmt.Printf("Could not delete %s: %v\n", ref, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Deleted %s\n", ref)
+	}
+	if len(hubRepos) > 0 {
+		dapi := getAvailableDockerApi()
+		if !dapi.IsAuthenticated() {
+			fmt.Printf("You need to login first.\n")
+			os.Exit(1)
+		}
+		for _, ref := range hubRepos {
+			failed = !deleteHubManifests(dapi, ref, hubDigests[ref.Repository()], manifestRmYes) || failed
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+//deleteHubManifests deletes manifests from a docker hub repository and reports what was removed.
+//If docker hub warns about some of the manifests they're listed, and deleted only when confirmed.
+func deleteHubManifests(dapi *api.DockerApi, ref *api.ImageReference, digests []string, yes bool) bool {
+	result, err := dapi.DeleteImages(ref.Namespace, ref.Name, nil, digests...)
+	if warnings, ok := err.(api.ImageDeletionWarnings); ok {
+		warnings, err = describeDeletionWarnings(dapi, ref, warnings)
+		if err != nil {
+			fmt.Printf("Could not list the tags of %s: %v\n", ref.Repository(), err)
+			return false
+		}
+		if !yes && !confirm(fmt.Sprintf("Delete from %s anyway?", ref.Repository())) {
+			fmt.Printf("Nothing was deleted from %s\n", ref.Repository())
+			return true
+		}
+		result, err = dapi.DeleteImages(ref.Namespace, ref.Name, warnings, digests...)
+	}
+	if err != nil {
+		fmt.Printf("Could not delete manifests from %s: %v\n", ref.Repository(), err)
+		return false
+	}
+	fmt.Printf("Deleted %d %s and %d %s from %s\n",
+		result.ManifestDeletes, plural(result.ManifestDeletes, "manifest", "manifests"),
+		result.TagDeletes, plural(result.TagDeletes, "tag", "tags"), ref.Repository())
+	if result.ManifestErrors > 0 || result.TagErrors > 0 {
+		fmt.Printf("Failed to delete %d %s and %d %s\n",
+			result.ManifestErrors, plural(result.ManifestErrors, "manifest", "manifests"),
+			result.TagErrors, plural(result.TagErrors, "tag", "tags"))
+		return false
+	}
+	return true
+}
+
+//describeDeletionWarnings prints the warnings docker hub gave about deleting manifests,
+//and adds the tags that still point to the manifests so that the warnings can be acknowledged
+func describeDeletionWarnings(dapi *api.DockerApi, ref *api.ImageReference, warnings api.ImageDeletionWarnings) (api.ImageDeletionWarnings, error) {
+	var tags api.TagList
+	for i, warning := range warnings {
+		switch warning.Warning {
+		case "current_tag":
+			if tags == nil {
+				var err error
+				tags, err = dapi.GetAllTags(ref.Namespace, ref.Name)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for _, tag := range tags {
+				if tagPointsTo(tag, warning.Digest) {
+					warnings[i].Tags = append(warnings[i].Tags, tag.Name)
+				}
+			}
+			fmt.Printf("%s is still tagged as %s\n", warning.Digest, strings.Join(warnings[i].Tags, ", "))
+		case "is_active":
+			fmt.Printf("%s was pulled recently\n", warning.Digest)
+		default:
+			fmt.Printf("%s: %s\n", warning.Digest, warning.Warning)
+		}
+	}
+	return warnings, nil
+}
+
+//tagPointsTo checks if a tag points to a manifest, either directly or through a manifest list
+func tagPointsTo(tag api.Tag, digest string) bool {
+	if tag.Digest == digest {
+		return true
+	}
+	for _, image := range tag.Images {
+		if image.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var pinCheck bool
+var pinBuildArgs []string
+
+func init() {
+	pinCmd := &cobra.Command{
+		Use:   "pin [Dockerfile...]",
+		Short: "Pin the base images of Dockerfiles to their digests",
+		Long: "Resolves the base image of every stage to its current digest and rewrites FROM image:tag to FROM image:tag@sha256:...\n" +
+			"Images without a tag are pinned as image:latest@sha256:... If no Dockerfile is given then ./Dockerfile is used.",
+		Run: pinCommand,
+	}
+	pinCmd.Flags().BoolVar(&pinCheck, "check", false, "Don't rewrite anything, exit with an error if any base image is unpinned or stale")
+	pinCmd.Flags().StringArrayVar(&pinBuildArgs, "build-arg", nil, "Set a build argument used in FROM instructions (KEY=VALUE)")
+	rootCmd.AddCommand(pinCmd)
+}
+
+func pinCommand(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		args = []string{"Dockerfile"}
+	}
+	buildArgs := make(map[string]string)
+	for _, arg := range pinBuildArgs {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			fmt.Printf("Invalid build argument: %s\n", arg)
+			os.Exit(1)
+		}
+		buildArgs[parts[0]] = parts[1]
+	}
+	digests := make(map[string]string)
+	resolve := func(ref *api.ImageReference) (string, error) {
+		if digest, ok := digests[ref.String()]; ok {
+			return digest, nil
+		}
+		desc, err := getRegistryClient(ref).HeadManifest(ref.Repository(), ref.Reference())
+		if err != nil {
+			return "", err
+		}
+		digests[ref.String()] = desc.Digest
+		return desc.Digest, nil
+	}
+	failed := false
+	for _, file := range args {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Printf("Could not read %s: %v\n", file, err)
+			failed = true
+			continue
+		}
+		dockerfile, err := api.ParseDockerfile(string(content), buildArgs)
+		if err != nil {
+			fmt.Printf("Could not parse %s: %v\n", file, err)
+			failed = true
+			continue
+		}
+		updated := false
+		for _, pin := range dockerfile.Pin(resolve) {
+			switch {
+			case pin.IsPinned():
+				fmt.Printf("%s: %s is pinned\n", file, pin.Stage.Image)
+			case pin.Digest == "":
+				fmt.Printf("%s: %s\n", file, pin.Status)
+				failed = true
+			case pinCheck:
+				fmt.Printf("%s: %s is %s, current digest is %s\n", file, pin.Stage.Image, pin.Status, pin.Digest)
+				failed = true
+			case pin.Updated:
+				fmt.Printf("%s: pinned %s to %s\n", file, pin.Stage.Image, pin.Digest)
+				updated = true
+			default:
+				fmt.Printf("%s: %s is %s but could not be rewritten, current digest is %s\n", file, pin.Stage.Original, pin.Status, pin.Digest)
+				failed = true
+			}
+		}
+		if updated && !pinCheck {
+			err = ioutil.WriteFile(file, []byte(dockerfile.String()), 0644)
+			if err != nil {
+				fmt.Printf("Could not write %s: %v\n", file, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var cloneSettingsOnly string
+var cloneSettingsYes bool
+var cloneSettingsDryRun bool
+
+func init() {
+	cloneSettingsRepoCmd := &cobra.Command{
+		Use:   "clone-settings [username/source] [username/target]",
+		Short: "Copy the description, privacy, collaborators, webhooks and builds of a repository to another one",
+		Long: "Shows the changes that give the target the settings of the source and makes them once you confirm.\n" +
+			"The target is created if it doesn't exist. Settings are only added, whatever only the target has is kept.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("a source and a target repository are required")
+			} else if len(args) > 2 {
+				return errors.New("only one source and one target repository accepted")
+			}
+			return nil
+		},
+		Run: cloneSettingsRepoCommand,
+	}
+	cloneSettingsRepoCmd.Flags().StringVar(&cloneSettingsOnly, "only", "", "Only clone these settings, like webhooks,collaborators. One of description, privacy, collaborators, webhooks and builds")
+	cloneSettingsRepoCmd.Flags().BoolVarP(&cloneSettingsYes, "yes", "y", false, "Don't ask for confirmation")
+	cloneSettingsRepoCmd.Flags().BoolVar(&cloneSettingsDryRun, "dry-run", false, "Only show the changes")
+	reposCmd.AddCommand(cloneSettingsRepoCmd)
+}
+
+func cloneSettingsRepoCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	kinds, err := api.ParseSettingKinds(cloneSettingsOnly)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	var refs []*api.ImageReference
+	for _, arg := range args {
+		ref, err := api.ParseReference(arg)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		refs = append(refs, ref)
+	}
+	src, dst := refs[0], refs[1]
+	srcName, dstName := src.Namespace+"/"+src.Name, dst.Namespace+"/"+dst.Name
+	if srcName == dstName {
+		fmt.Printf("The source and the target are the same repository.\n")
+		os.Exit(1)
+	}
+	source, err := dapi.GetRepositorySettings(src.Namespace, src.Name, kinds)
+	if err != nil {
+		fmt.Printf("Could not read the settings of %s: %v\n", srcName, err)
+		os.Exit(1)
+	}
+	if source.Repository == nil {
+		fmt.Printf("Repository %s not found.\n", srcName)
+		os.Exit(1)
+	}
+	target, err := dapi.GetRepositorySettings(dst.Namespace, dst.Name, kinds)
+	if err != nil {
+		fmt.Printf("Could not read the settings of %s: %v\n", dstName, err)
+		os.Exit(1)
+	}
+	selected := make(map[string]bool)
+	for _, kind := range kinds {
+		selected[kind] = true
+	}
+	create := target.Repository == nil
+	if create {
+		//The target is created with the selected settings of the source, but a clone of a private repository is never public
+		target.Repository = &api.Repository{
+			Namespace: dst.Namespace,
+			Name:      dst.Name,
+			IsPrivate: source.Repository.IsPrivate,
+		}
+		if selected[api.SettingDescription] {
+			target.Repository.Description = source.Repository.Description
+			target.Repository.FullDescription = source.Repository.FullDescription
+		}
+	}
+	changes := api.PlanSettingsClone(source, target, kinds)
+	if !create && len(changes) == 0 {
+		fmt.Printf("%s already has the settings of %s.\n", dstName, srcName)
+		return
+	}
+	fmt.Printf("Changes to %s:\n", dstName)
+	makesPrivate := create && target.Repository.IsPrivate
+	if create {
+		visibility := "public"
+		if target.Repository.IsPrivate {
+			visibility = "private"
+		}
+		line := "create the repository as " + visibility
+		if selected[api.SettingDescription] {
+			line += " with the descriptions of " + srcName
+		}
+		if target.Repository.IsPrivate && !selected[api.SettingPrivacy] {
+			line += ", since " + srcName + " is private"
+		}
+		fmt.Printf("  %s\n", line)
+	}
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change.Summary)
+		if change.Kind == api.SettingPrivacy && source.Repository.IsPrivate {
+			makesPrivate = true
+		}
+	}
+	if makesPrivate {
+		checkPrivateRepoQuota(dapi, dst.Namespace, 1)
+	}
+	if cloneSettingsDryRun {
+		return
+	}
+	if !cloneSettingsYes && !confirm("Apply these changes?") {
+		os.Exit(1)
+	}
+	if create {
+		repo := target.Repository
+		_, err = dapi.CreateRepository(dst.Namespace, dst.Name, repo.IsPrivate, repo.Description, repo.FullDescription)
+		if err != nil {
+			fmt.Printf("Could not create repository %s: %v\n", dstName, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created repository %s\n", dstName)
+	}
+	failed := 0
+	for _, change := range changes {
+		err = change.Apply(dapi, dst.Namespace, dst.Name)
+		if err != nil {
+			fmt.Printf("Could not %s: %v\n", change.Summary, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Done: %s\n", change.Summary)
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d %s failed.\n", failed, len(changes), plural(len(changes), "change", "changes"))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var repoCreatePrivate bool
+var repoCreateDescription string
+var repoCreateFullDescriptionFile string
+var repoCreateNamespace string
+var repoCreateBuildFrom string
+var repoCreateBuildTags []string
+var repoCreateDockerfile string
+var repoCreateWebhooks []string
+
+func init() {
+	createRepoCmd := &cobra.Command{
+		Use:   "create [repository]",
+		Short: "Create a repository",
+		Long: "Creates a repository in your namespace, or in the namespace of an organization with --namespace or organization/repository.\n" +
+			"Automated builds and webhooks can be set up right away, if any of them fails the repository is still kept.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one repository accepted")
+			} else if len(args) < 1 {
+				return errors.New("repository is missing")
+			}
+			return nil
+		},
+		Run: createRepoCommand,
+	}
+	createRepoCmd.Flags().BoolVar(&repoCreatePrivate, "private", false, "Make the repository private")
+	createRepoCmd.Flags().StringVar(&repoCreateDescription, "description", "", "The short description of the repository")
+	createRepoCmd.Flags().StringVar(&repoCreateFullDescriptionFile, "full-description-file", "", "A file, like README.md, with the full description of the repository")
+	createRepoCmd.Flags().StringVar(&repoCreateNamespace, "namespace", "", "The user or organization to create the repository in, your own by default")
+	createRepoCmd.Flags().StringVar(&repoCreateBuildFrom, "build-from", "", "Build the images automatically from a git repository, like github.com/owner/repo or bitbucket.org/owner/repo")
+	createRepoCmd.Flags().StringArrayVar(&repoCreateBuildTags, "build-tag", nil, "A tag to build, as tag=branch or tag=tag:gittag, can be repeated. Defaults to latest=master")
+	createRepoCmd.Flags().StringVar(&repoCreateDockerfile, "dockerfile", "/", "The location of the Dockerfile in the git repository for the built tags")
+	createRepoCmd.Flags().StringArrayVar(&repoCreateWebhooks, "webhook", nil, "A webhook to call on pushes, as name=url, can be repeated")
+	reposCmd.AddCommand(createRepoCmd)
+}
+
+//buildTag is a tag that an automated build creates from a branch or git tag
+type buildTag struct {
+	name       string
+	sourceType string
+	sourceName string
+}
+
+//parseBuildTag parses a build tag like latest=master or stable=tag:v1.0
+func parseBuildTag(str string) (buildTag, error) {
+	parts := strings.SplitN(str, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return buildTag{}, fmt.Errorf("invalid build tag %s, use tag=branch or tag=tag:gittag", str)
+	}
+	tag := buildTag{name: parts[0], sourceType: "Branch", sourceName: parts[1]}
+	if strings.HasPrefix(parts[1], "tag:") {
+		tag.sourceType, tag.sourceName = "Tag", strings.TrimPrefix(parts[1], "tag:")
+	} else if strings.HasPrefix(parts[1], "branch:") {
+		tag.sourceName = strings.TrimPrefix(parts[1], "branch:")
+	}
+	return tag, nil
+}
+
+//parseBuildSource parses a git repository like github.com/owner/repo into its provider and repository name
+func parseBuildSource(str string) (string, string, error) {
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "https://"), "http://")
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "/"), ".git")
+	parts := strings.SplitN(str, "/", 2)
+	if len(parts) != 2 || strings.Count(parts[1], "/") != 1 {
+		return "", "", fmt.Errorf("invalid git repository %s, use github.com/owner/repo or bitbucket.org/owner/repo", str)
+	}
+	switch parts[0] {
+	case "github.com":
+		return "github", parts[1], nil
+	case "bitbucket.org":
+		return "bitbucket", parts[1], nil
+	}
+	return "", "", fmt.Errorf("builds from %s are not supported, only from github.com and bitbucket.org", parts[0])
+}
+
+//parseWebhook parses a webhook like name=url
+func parseWebhook(str string) (string, string, error) {
+	parts := strings.SplitN(str, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || !strings.HasPrefix(parts[1], "http") {
+		return "", "", fmt.Errorf("invalid webhook %s, use name=url", str)
+	}
+	return parts[0], parts[1], nil
+}
+
+func createRepoCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	namespace, name := repoCreateNamespace, args[0]
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		if namespace != "" && namespace != parts[0] {
+			fmt.Printf("The repository %s is not in the namespace %s.\n", name, namespace)
+			os.Exit(1)
+		}
+		namespace, name = parts[0], parts[1]
+	}
+	if namespace == "" {
+		namespace = dapi.GetUsername()
+	}
+	//Everything is validated before the repository is created, so that a typo doesn't leave a half set up repository
+	var fullDescription string
+	if repoCreateFullDescriptionFile != "" {
+		content, err := ioutil.ReadFile(repoCreateFullDescriptionFile)
+		if err != nil {
+			fmt.Printf("Could not read the full description: %v\n", err)
+			os.Exit(1)
+		}
+		fullDescription = string(content)
+	}
+	var provider, vcsRepo string
+	var tags []buildTag
+	if repoCreateBuildFrom != "" {
+		var err error
+		provider, vcsRepo, err = parseBuildSource(repoCreateBuildFrom)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		if len(repoCreateBuildTags) == 0 {
+			repoCreateBuildTags = []string{"latest=master"}
+		}
+		for _, str := range repoCreateBuildTags {
+			tag, err := parseBuildTag(str)
+			if err != nil {
+				fmt.Printf("%v\n", err)
+				os.Exit(1)
+			}
+			tags = append(tags, tag)
+		}
+	} else if len(repoCreateBuildTags) > 0 {
+		fmt.Printf("Build tags need a git repository to build from, use --build-from.\n")
+		os.Exit(1)
+	}
+	webhooks := make(map[string]string)
+	var webhookNames []string
+	for _, str := range repoCreateWebhooks {
+		hookName, hookUrl, err := parseWebhook(str)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		if _, exists := webhooks[hookName]; !exists {
+			webhookNames = append(webhookNames, hookName)
+		}
+		webhooks[hookName] = hookUrl
+	}
+
+	repo, err := dapi.CreateRepository(namespace, name, repoCreatePrivate, repoCreateDescription, fullDescription)
+	if err != nil {
+		fmt.Printf("Could not create repository %s/%s: %v\n", namespace, name, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Created repository %s/%s\n", repo.Namespace, repo.Name)
+	failed := false
+	if provider != "" {
+		err = dapi.CreateAutomatedBuild(repo.Namespace, repo.Name, map[string]interface{}{
+			"provider":      provider,
+			"vcs_repo_name": vcsRepo,
+			"is_private":    repoCreatePrivate,
+		})
+		if err != nil {
+			fmt.Printf("Could not set up automated builds from %s: %v\n", repoCreateBuildFrom, err)
+			failed = true
+		} else {
+			fmt.Printf("Building from %s\n", repoCreateBuildFrom)
+			for _, tag := range tags {
+				err = dapi.CreateBuildTag(repo.Namespace, repo.Name, tag.name, repoCreateDockerfile, tag.sourceType, tag.sourceName)
+				if err != nil {
+					fmt.Printf("Could not add build tag %s: %v\n", tag.name, err)
+					failed = true
+					continue
+				}
+				fmt.Printf("Building tag %s from %s %s\n", tag.name, strings.ToLower(tag.sourceType), tag.sourceName)
+			}
+		}
+	}
+	for _, hookName := range webhookNames {
+		_, err = dapi.CreateWebhook(repo.Namespace, repo.Name, hookName, webhooks[hookName])
+		if err != nil {
+			fmt.Printf("Could not create webhook %s: %v\n", hookName, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Created webhook %s\n", hookName)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var privacyYes bool
+var privacyDryRun bool
+
+func init() {
+	privacyRepoCmd := &cobra.Command{
+		Use:   "privacy [username/repo or username...] [public|private]",
+		Short: "Make repositories public or private",
+		Long: "Changes the visibility of the given repositories. A username or organization selects its repositories that match --filter, " +
+			"repositories that are named explicitly are always changed.\n" +
+			"Before making repositories private the private repository quota is checked, nothing is changed if there aren't enough left.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("a repository and public or private are required")
+			}
+			visibility := args[len(args)-1]
+			if visibility != "public" && visibility != "private" {
+				return fmt.Errorf("invalid visibility %s, use public or private", visibility)
+			}
+			return nil
+		},
+		Run: privacyRepoCommand,
+	}
+	privacyRepoCmd.Flags().BoolVarP(&privacyYes, "yes", "y", false, "Don't ask for confirmation when changing more than one repository")
+	privacyRepoCmd.Flags().BoolVar(&privacyDryRun, "dry-run", false, "Only list the repositories that would change")
+	addFilterFlag(privacyRepoCmd, repoFilterFields, api.UserRepository{})
+	reposCmd.AddCommand(privacyRepoCmd)
+}
+
+//selectPrivacyRepositories gets the repositories that the arguments name, grouped by namespace.
+//Whole namespaces need a --filter, so that a missing repository name doesn't change all of them.
+//The filter only selects from whole namespaces, repositories that are named explicitly are always selected.
+func selectPrivacyRepositories(dapi *api.DockerApi, args []string) (map[string]api.RepositoryList, []string) {
+	names := make(map[string][]string)
+	var namespaces []string
+	for _, arg := range args {
+		namespace, name := strings.ToLower(arg), ""
+		if parts := strings.SplitN(namespace, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		} else if listFilter == "" {
+			fmt.Printf("%s is a namespace, use --filter to select which of its repositories to change.\n", arg)
+			os.Exit(1)
+		}
+		if _, exists := names[namespace]; !exists {
+			namespaces = append(namespaces, namespace)
+		}
+		names[namespace] = append(names[namespace], name)
+	}
+	selected := make(map[string]api.RepositoryList)
+	for _, namespace := range namespaces {
+		repos, err := dapi.GetRepositories(namespace)
+		if err != nil {
+			fmt.Printf("Could not get the repositories of %s: %v\n", namespace, err)
+			os.Exit(1)
+		}
+		found := make(map[string]bool)
+		for _, repo := range repos {
+			for _, name := range names[namespace] {
+				if name == repo.Name || (name == "" && matchesListFilter(repo)) {
+					selected[namespace] = append(selected[namespace], repo)
+					found[name] = true
+					break
+				}
+			}
+		}
+		for _, name := range names[namespace] {
+			if name != "" && !found[name] {
+				fmt.Printf("Repository %s/%s not found.\n", namespace, name)
+				os.Exit(1)
+			}
+		}
+	}
+	return selected, namespaces
+}
+
+//checkPrivateRepoQuota reports the private repository quota of a namespace and exits if it doesn't have enough private repositories left
+func checkPrivateRepoQuota(dapi *api.DockerApi, namespace string, needed int) {
+	settings, err := dapi.GetRegistrySettings(namespace)
+	if err != nil {
+		fmt.Printf("Could not check the private repository quota of %s: %v\n", namespace, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s uses %d of %d private repositories.\n", namespace, settings.NumPrivateRepos, settings.PrivateRepoLimit)
+	if remaining := settings.PrivateReposRemaining(); needed > remaining {
+		fmt.Printf("Making %d %s private needs more than the %d that %s has left.\n", needed, plural(needed, "repository", "repositories"), remaining, namespace)
+		os.Exit(1)
+	}
+}
+
+func privacyRepoCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	private := args[len(args)-1] == "private"
+	selected, namespaces := selectPrivacyRepositories(dapi, args[:len(args)-1])
+	var changes api.RepositoryList
+	for _, namespace := range namespaces {
+		var namespaceChanges api.RepositoryList
+		for _, repo := range selected[namespace] {
+			if repo.IsPrivate != private {
+				namespaceChanges = append(namespaceChanges, repo)
+			}
+		}
+		if len(namespaceChanges) == 0 {
+			continue
+		}
+		if private {
+			checkPrivateRepoQuota(dapi, namespace, len(namespaceChanges))
+		}
+		changes = append(changes, namespaceChanges...)
+	}
+	visibility := args[len(args)-1]
+	if len(changes) == 0 {
+		fmt.Printf("All the repositories are already %s.\n", visibility)
+		return
+	}
+	for _, repo := range changes {
+		fmt.Printf("%s/%s\n", repo.Namespace, repo.Name)
+	}
+	if privacyDryRun {
+		fmt.Printf("Would make %d %s %s.\n", len(changes), plural(len(changes), "repository", "repositories"), visibility)
+		return
+	}
+	if len(changes) > 1 && !privacyYes && !confirm(fmt.Sprintf("Make these %d repositories %s?", len(changes), visibility)) {
+		os.Exit(1)
+	}
+	failed := 0
+	for _, repo := range changes {
+		err := dapi.SetRepositoryPrivacy(repo.Namespace, repo.Name, private)
+		if err != nil {
+			fmt.Printf("Could not make %s/%s %s: %v\n", repo.Namespace, repo.Name, visibility, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Made %s/%s %s\n", repo.Namespace, repo.Name, visibility)
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d %s could not be changed.\n", failed, len(changes), plural(len(changes), "repository", "repositories"))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var readmeShort string
+var readmeGitRepo string
+var readmeGitRef string
+var readmeGitDir string
+var readmeYes bool
+
+var readmeCmd = &cobra.Command{
+	Use:   "readme",
+	Short: "Sync README files with the full descriptions of repositories",
+	Long: "Keeps the full description of a repository in sync with a README that's maintained in git.\n" +
+		"Relative links and images are resolved against the git repository, from --git-repo or the one the repository builds from.",
+}
+
+func init() {
+	pushReadmeCmd := &cobra.Command{
+		Use:   "push [username/repo] [file]",
+		Short: "Set the full description of a repository from a README file",
+		Long: "Shows how the full description changes and sets it once you confirm.\n" +
+			"Relative links and images are rewritten to absolute urls of the git repository, images point to the raw files.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("repository is missing")
+			} else if len(args) < 2 {
+				return errors.New("README file is missing")
+			} else if len(args) > 2 {
+				return errors.New("only one repository and file accepted")
+			}
+			return nil
+		},
+		Run: pushReadmeCommand,
+	}
+	pushReadmeCmd.Flags().StringVar(&readmeShort, "short", "", "Also set the short description")
+	pullReadmeCmd := &cobra.Command{
+		Use:   "pull [username/repo] [file]",
+		Short: "Write the full description of a repository to a README file",
+		Long: "Shows how the file changes and writes it once you confirm, or prints the description if the file is -.\n" +
+			"Links to the git repository are turned back into relative links, so pushing and pulling gives back the same file.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("repository is missing")
+			} else if len(args) > 2 {
+				return errors.New("only one repository and file accepted")
+			}
+			return nil
+		},
+		Run: pullReadmeCommand,
+	}
+	for _, c := range []*cobra.Command{pushReadmeCmd, pullReadmeCmd} {
+		c.Flags().StringVar(&readmeGitRepo, "git-repo", "", "The git repository of the README, like github.com/owner/repo")
+		c.Flags().StringVar(&readmeGitRef, "ref", "master", "The branch, tag or commit that links point to")
+		c.Flags().StringVar(&readmeGitDir, "dir", "", "The directory of the README in the git repository")
+		c.Flags().BoolVarP(&readmeYes, "yes", "y", false, "Don't ask for confirmation")
+		readmeCmd.AddCommand(c)
+	}
+	reposCmd.AddCommand(readmeCmd)
+}
+
+//getReadmeRepository gets the repository whose description is synced
+func getReadmeRepository(dapi *api.DockerApi, arg string) *api.Repository {
+	ref, err := api.ParseReference(arg)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	repo, err := dapi.GetRepository(ref.Namespace, ref.Name)
+	if err != nil {
+		fmt.Printf("Could not fetch %s: %v\n", arg, err)
+		os.Exit(1)
+	}
+	return repo
+}
+
+//getReadmeSource gets the git repository that the links of the README are relative to, nil if there's none
+func getReadmeSource(repo *api.Repository) *api.GitSource {
+	gitRepo := readmeGitRepo
+	if gitRepo == "" {
+		gitRepo = repo.GetGitRepo()
+		if gitRepo == "" {
+			return nil
+		}
+	}
+	src, err := api.ParseGitSource(gitRepo, readmeGitRef, readmeGitDir)
+	if err != nil {
+		if readmeGitRepo != "" {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return nil
+	}
+	return src
+}
+
+func pushReadmeCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	repo := getReadmeRepository(dapi, args[0])
+	content, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		fmt.Printf("Could not read %s: %v\n", args[1], err)
+		os.Exit(1)
+	}
+	readme := string(content)
+	if src := getReadmeSource(repo); src != nil {
+		readme = src.AbsoluteLinks(readme)
+	} else if links := api.FindRelativeLinks(readme); len(links) > 0 {
+		fmt.Printf("%s has relative links that won't work on the hub, like %s. Use --git-repo to point them to the git repository.\n", args[1], links[0])
+		os.Exit(1)
+	}
+	err = api.ValidateDescriptions(readmeShort, readme)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	fullName := repo.Namespace + "/" + repo.Name
+	diff := api.UnifiedDiff(fullName, args[1], repo.FullDescription, readme, 3)
+	shortChanged := readmeShort != "" && readmeShort != repo.Description
+	if diff == "" && !shortChanged {
+		fmt.Printf("The description of %s is up to date.\n", fullName)
+		return
+	}
+	if shortChanged {
+		fmt.Printf("Short description: %q -> %q\n", repo.Description, readmeShort)
+	}
+	fmt.Print(diff)
+	if !readmeYes && !confirm(fmt.Sprintf("Update the description of %s?", fullName)) {
+		os.Exit(1)
+	}
+	//Only the descriptions that changed are sent, so an empty readme still clears the full description
+	var short, full *string
+	if shortChanged {
+		short = &readmeShort
+	}
+	if diff != "" {
+		full = &readme
+	}
+	err = dapi.UpdateRepositoryDescription(repo.Namespace, repo.Name, short, full)
+	if err != nil {
+		fmt.Printf("Could not update the description of %s: %v\n", fullName, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated the description of %s\n", fullName)
+}
+
+func pullReadmeCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	repo := getReadmeRepository(dapi, args[0])
+	readme := repo.FullDescription
+	if src := getReadmeSource(repo); src != nil {
+		readme = src.RelativeLinks(readme)
+	}
+	if readme != "" && !strings.HasSuffix(readme, "\n") {
+		readme += "\n"
+	}
+	file := "README.md"
+	if len(args) > 1 {
+		file = args[1]
+	}
+	if file == "-" {
+		fmt.Print(readme)
+		return
+	}
+	var current string
+	content, err := ioutil.ReadFile(file)
+	if err == nil {
+		current = string(content)
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("Could not read %s: %v\n", file, err)
+		os.Exit(1)
+	}
+	diff := api.UnifiedDiff(file, repo.Namespace+"/"+repo.Name, current, readme, 3)
+	if diff == "" && err == nil {
+		fmt.Printf("%s is up to date.\n", file)
+		return
+	}
+	fmt.Print(diff)
+	if !readmeYes && !confirm(fmt.Sprintf("Write %s?", file)) {
+		os.Exit(1)
+	}
+	err = ioutil.WriteFile(file, []byte(readme), 0644)
+	if err != nil {
+		fmt.Printf("Could not write %s: %v\n", file, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", file)
+}
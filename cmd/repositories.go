@@ -1,35 +1,56 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	log "github.com/sirupsen/logrus"
 	"github.com/sp0x/docker-hub-cli/api"
 	"github.com/spf13/cobra"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 var repoShowTags bool
+var repoTagPlatform string
+var repoGroupTags bool
+var repoListSort string
+var repoListPrivate bool
+var repoListPublic bool
+var repoRmYes bool
+var repoRmForce bool
+var repoRmPullThreshold int
+var repoRmBackupDir string
+
+const repoFilterFields = "name, namespace, description, pulls, stars, updated, private, automated, type, status"
+
+var reposCmd = &cobra.Command{
+	Use:   "repo [username or username/repo]",
+	Short: "View, Create, Delete repositories",
+	Long:  "Use this to explore repositories or to manage them. If no username is given then the logged in user is used.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		//if len(args) > 1 {
+		//	return errors.New("only one username accepted")
+		//}
+		return nil
+	},
+	Run: reposCommand,
+}
 
 func init() {
-	reposCmd := &cobra.Command{
-		Use:   "repo [username or username/repo]",
-		Short: "View, Create, Delete repositories",
-		Long:  "Use this to explore repositories or to manage them. If no username is given then the logged in user is used.",
-		Args: func(cmd *cobra.Command, args []string) error {
-			//if len(args) > 1 {
-			//	return errors.New("only one username accepted")
-			//}
-			return nil
-		},
-		Run: reposCommand,
-	}
 	reposCmd.Flags().BoolVarP(&repoShowTags, "tags", "t", false, "Also shows all the tags in the repository")
+	reposCmd.Flags().StringVar(&repoTagPlatform, "platform", "", "Only show tags that have an image for this platform, like linux/arm64/v8")
+	reposCmd.Flags().BoolVar(&repoGroupTags, "group", false, "Show the tags, with tags that point to the same image as a single row")
 
 	rmRepoCmd := &cobra.Command{
 		Use:   "rm [repository]",
 		Short: "Delete a repository",
+		Long: "Deletes a repository once you confirm by typing its full name. If no username is given then your own repository is deleted.\n" +
+			"The metadata, settings and tags of the repository are backed up to a file first, popular repositories also need --force.",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 1 {
 				return errors.New("only one repository accepted")
@@ -40,19 +61,10 @@ func init() {
 		},
 		Run: rmRepoCommand,
 	}
-	createRepoCmd := &cobra.Command{
-		Use:   "create [repository]",
-		Short: "Delete a repository",
-		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 1 {
-				return errors.New("only one repository accepted")
-			} else if len(args) < 1 {
-				return errors.New("repository is missing")
-			}
-			return nil
-		},
-		Run: createRepoCommand,
-	}
+	rmRepoCmd.Flags().BoolVarP(&repoRmYes, "yes", "y", false, "Delete without asking for confirmation")
+	rmRepoCmd.Flags().BoolVar(&repoRmForce, "force", false, "Delete even if the repository has more pulls than the threshold")
+	rmRepoCmd.Flags().IntVar(&repoRmPullThreshold, "pull-threshold", 1000, "Refuse to delete repositories with more pulls than this, unless --force is given")
+	rmRepoCmd.Flags().StringVar(&repoRmBackupDir, "backup-dir", "", "The directory for the backup, ~/.docker-hub-cli.d/backups by default")
 	lsReposCmd := &cobra.Command{
 		Use:   "ls [username]",
 		Short: "Lists the repositories of a given user",
@@ -62,13 +74,33 @@ func init() {
 		},
 		Run: listUserReposCommand,
 	}
+	lsReposCmd.Flags().StringVar(&repoListSort, "sort", "name", "Sort the repositories by name, pulls, stars or updated")
+	lsReposCmd.Flags().BoolVar(&repoListPrivate, "private", false, "Only list private repositories")
+	lsReposCmd.Flags().BoolVar(&repoListPublic, "public", false, "Only list public repositories")
+	addFilterFlag(lsReposCmd, repoFilterFields, api.UserRepository{})
+	//Without arguments repositories are listed, otherwise the filter is for the tags of the given repositories
+	reposCmd.PreRun = func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			parseListFilter(api.UserRepository{})
+			return
+		}
+		if listFilter != "" && !repoShowTags && !repoGroupTags {
+			fmt.Printf("--filter only filters the tags of a repository, use it with --tags\n")
+			os.Exit(1)
+		}
+		parseListFilter(api.Tag{})
+	}
+	addFilterFlag(reposCmd, repoFilterFields+", or with --tags "+tagFilterFields, nil)
 	reposCmd.AddCommand(lsReposCmd)
 	reposCmd.AddCommand(rmRepoCmd)
-	reposCmd.AddCommand(createRepoCmd)
 	rootCmd.AddCommand(reposCmd)
 }
 
 func listUserReposCommand(cmd *cobra.Command, args []string) {
+	if repoListPrivate && repoListPublic {
+		fmt.Printf("Use either --private or --public, not both.\n")
+		os.Exit(1)
+	}
 	dapi := getAvailableDockerApi()
 	var users []string
 	if len(args) == 0 && dapi.IsAuthenticated() {
@@ -76,25 +108,61 @@ func listUserReposCommand(cmd *cobra.Command, args []string) {
 	} else {
 		users = append(users, args...)
 	}
+	var listed api.RepositoryList
 	for _, user := range users {
 		if user == "_" {
 			user = "library"
 		}
 		repos, err := dapi.GetRepositories(user)
 		if err != nil {
-			fmt.Printf("Could not get repositories for user: %s\n", user)
+			fmt.Printf("Could not get repositories for user %s: %v\n", user, err)
 			continue
 		}
 		for _, repo := range repos {
-			fmt.Printf("%s/%s\n", repo.Namespace, repo.Name)
+			if repoListPrivate && !repo.IsPrivate || repoListPublic && repo.IsPrivate {
+				continue
+			}
+			if matchesListFilter(repo) {
+				listed = append(listed, repo)
+			}
 		}
 	}
+	err := listed.Sort(repoListSort)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	printRepositories(listed)
+}
+
+//printRepositories prints a table with the summary of each repository
+func printRepositories(repos api.RepositoryList) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "NAME\tDESCRIPTION\tPULLS\tSTARS\tVISIBILITY\tAUTOMATED\tSTATUS\tUPDATED\n")
+	for _, repo := range repos {
+		visibility := "public"
+		if repo.IsPrivate {
+			visibility = "private"
+		}
+		updated := "never"
+		if repo.LastUpdated != nil {
+			updated = timeElapsedRightNow(*repo.LastUpdated, false)
+		}
+		automated := "no"
+		if repo.IsAutomated {
+			automated = "yes"
+		}
+		_, _ = fmt.Fprintf(w, "%s/%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", repo.Namespace, repo.Name,
+			truncate(repo.Description, 50), repo.PullCount, repo.StarCount, visibility, automated, repo.StatusName(), updated)
+	}
+	_ = w.Flush()
 }
 
 func reposCommand(cmd *cobra.Command, args []string) {
 	var dapi *api.DockerApi
 	var err error
-	var repos []api.UserRepository
+	var repos api.RepositoryList
 	if len(args) > 0 {
 		dapi = getAvailableDockerApi()
 		for _, arg := range args {
@@ -109,7 +177,9 @@ func reposCommand(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 		for _, repo := range repos {
-			fmt.Printf("%s/%s\n", repo.Namespace, repo.Name)
+			if matchesListFilter(repo) {
+				fmt.Printf("%s/%s\n", repo.Namespace, repo.Name)
+			}
 		}
 	}
 
@@ -128,27 +198,105 @@ func rmRepoCommand(cmd *cobra.Command, args []string) {
 		parts = append(parts, "")
 		parts[0], parts[1] = dapi.GetUsername(), parts[0]
 	}
-	err := dapi.DeleteRepository(parts[0], parts[1])
+	fullName := strings.ToLower(parts[0] + "/" + parts[1])
+	settings, err := dapi.GetRepositorySettings(parts[0], parts[1], nil)
+	if err != nil {
+		fmt.Printf("Could not read repository %s: %v\n", fullName, err)
+		os.Exit(1)
+	}
+	details := settings.Repository
+	if details == nil {
+		fmt.Printf("Repository %s not found.\n", fullName)
+		os.Exit(1)
+	}
+	if details.PullCount > repoRmPullThreshold && !repoRmForce {
+		fmt.Printf("%s has %d pulls, more than %d. Use --force to delete it anyway.\n", fullName, details.PullCount, repoRmPullThreshold)
+		os.Exit(1)
+	}
+	tags, err := dapi.GetAllTags(parts[0], parts[1])
+	if err != nil {
+		fmt.Printf("Could not get the tags of %s: %v\n", fullName, err)
+		os.Exit(1)
+	}
+	if !repoRmYes {
+		fmt.Printf("This deletes %s with all of its %d %s and %d pulls, it can't be undone.\n", fullName, len(tags), plural(len(tags), "tag", "tags"), details.PullCount)
+		if strings.ToLower(readLine("Type the full name of the repository to delete it: ")) != fullName {
+			fmt.Printf("The name didn't match, nothing was deleted.\n")
+			os.Exit(1)
+		}
+	}
+	readBackupSettings(dapi, settings, parts[0], parts[1])
+	backup, err := backupRepository(settings, tags)
+	if err != nil {
+		fmt.Printf("Could not back up %s, nothing was deleted: %v\n", fullName, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Backed up %s with %d %s to %s\n", fullName, len(tags), plural(len(tags), "tag", "tags"), backup)
+	err = dapi.DeleteRepository(parts[0], parts[1])
 	if err != nil {
 		fmt.Printf("Could not delete repository: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Removed repository %s/%s\n", parts[0], parts[1])
+	fmt.Printf("Removed repository %s\n", fullName)
 }
 
-func createRepoCommand(cmd *cobra.Command, args []string) {
-	dapi := getAvailableDockerApi()
-	if !dapi.IsAuthenticated() {
-		fmt.Printf("You need to login first.")
-		os.Exit(1)
+//readBackupSettings adds the collaborators, webhooks and build settings of a repository to its backup.
+//They're left out with a warning if they can't be read, since only the metadata and tags are needed to delete it.
+func readBackupSettings(dapi *api.DockerApi, settings *api.RepositorySettings, username, name string) {
+	var err error
+	settings.Collaborators, err = dapi.GetCollaborators(username, name)
+	if err != nil {
+		log.Warningf("Could not get the collaborators of %s/%s, they're left out of the backup: %v", username, name, err)
 	}
-	name := args[0]
-	repo, err := dapi.CreateOwnRepository(name, false, "", "")
+	settings.Webhooks, err = dapi.GetAllWebhooks(username, name)
 	if err != nil {
-		fmt.Printf("\n")
-		os.Exit(1)
+		log.Warningf("Could not get the webhooks of %s/%s, they're left out of the backup: %v", username, name, err)
+	}
+	settings.Build, err = dapi.GetBuildSettings(username, name)
+	if err != nil {
+		log.Warningf("Could not get the build settings of %s/%s, they're left out of the backup: %v", username, name, err)
 	}
-	fmt.Printf("Created repository: %s/%s", repo.Namespace, repo.Name)
+}
+
+//repositoryBackup is what's saved about a repository before it's deleted
+type repositoryBackup struct {
+	Time          time.Time          `json:"time"`
+	Repository    *api.Repository    `json:"repository"`
+	Collaborators []api.Collaborator `json:"collaborators"`
+	Webhooks      []api.Webhook      `json:"webhooks"`
+	Build         *api.BuildSettings `json:"build"`
+	Tags          api.TagList        `json:"tags"`
+}
+
+//backupRepository writes the metadata, settings and tags of a repository to a json file and returns its path
+func backupRepository(settings *api.RepositorySettings, tags api.TagList) (string, error) {
+	dir := repoRmBackupDir
+	if dir == "" {
+		dir = getDataPath("backups")
+	}
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	repo := settings.Repository
+	data, err := json.MarshalIndent(repositoryBackup{
+		Time:          now,
+		Repository:    repo,
+		Collaborators: settings.Collaborators,
+		Webhooks:      settings.Webhooks,
+		Build:         settings.Build,
+		Tags:          tags,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, fmt.Sprintf("%s_%s-%s.json", repo.Namespace, repo.Name, now.Format("20060102T150405")))
+	err = ioutil.WriteFile(file, data, 0600)
+	if err != nil {
+		return "", err
+	}
+	return file, nil
 }
 
 func showRepositoryDetails(dapi *api.DockerApi, fullName string) {
@@ -162,7 +310,6 @@ func showRepositoryDetails(dapi *api.DockerApi, fullName string) {
 		fmt.Printf("Could not fetch %s: %v", fullName, err)
 	}
 	gitRepo := repo.GetGitRepo()
-	tags, err := dapi.GetTagsFromRepo(repo, 0, 0)
 	fmt.Println(fullName)
 	fmt.Println(repo.Description)
 	fmt.Printf("Pulls: %d	Stars: %d\n", repo.PullCount, repo.StarCount)
@@ -172,7 +319,26 @@ func showRepositoryDetails(dapi *api.DockerApi, fullName string) {
 	if gitRepo != "" {
 		fmt.Printf("Git repo: %s\n", gitRepo)
 	}
-	if repoShowTags {
+	if repoShowTags || repoGroupTags {
+		//Filters and groups need every tag, not just the first page
+		tags, err := getAllTags(dapi, &api.ImageReference{Namespace: repo.Namespace, Name: repo.Name})
+		if err != nil {
+			fmt.Printf("Could not fetch tags for %s: %v\n", fullName, err)
+			os.Exit(1)
+		}
+		if repoTagPlatform != "" {
+			platform, err := api.ParsePlatform(repoTagPlatform)
+			if err != nil {
+				fmt.Printf("%v\n", err)
+				os.Exit(1)
+			}
+			tags = tags.FilterPlatform(platform)
+		}
+		tags = filterTagList(tags)
+		if repoGroupTags {
+			printTagGroups(tags)
+			return
+		}
 		w := new(tabwriter.Writer)
 		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
 		for _, tag := range tags {
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var retentionFile string
+var retentionShowKept bool
+var retentionYes bool
+var retentionConcurrency int
+
+//repositoryPlan is what a retention policy decided for the tags of a repository
+type repositoryPlan struct {
+	Repository *api.ImageReference
+	Decisions  []api.RetentionDecision
+}
+
+func (p *repositoryPlan) deletedTags() []string {
+	var output []string
+	for _, d := range p.Decisions {
+		if d.Delete {
+			output = append(output, d.Tag.Name)
+		}
+	}
+	return output
+}
+
+func init() {
+	retentionCmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Delete old tags with retention policies",
+		Long: "Retention policies are yaml files that decide which tags to keep and which to delete, for example\n\n" +
+			"policies:\n" +
+			"  - repositories: [user/app, user/app-*]\n" +
+			"    keep:\n" +
+			"      - most_recent: 10\n" +
+			"      - newest_per: minor\n" +
+			"      - match: release-*\n" +
+			"    delete:\n" +
+			"      - match: pr-*\n" +
+			"        older_than: 14d\n\n" +
+			"A tag is kept if any keep rule selects it, otherwise it's deleted if any delete rule selects it.\n" +
+			"Rules can use match (a glob), regex, older_than, newer_than, most_recent and newest_per (major, minor or patch).\n" +
+			"Tags that no rule selects are kept, unless the policy has `default: delete`.",
+	}
+	retentionCmd.PersistentFlags().StringVarP(&retentionFile, "file", "f", "retention.yaml", "The policy file")
+	planRetentionCmd := &cobra.Command{
+		Use:   "plan [username/repo...]",
+		Short: "Show which tags the policies would delete and why",
+		Long:  "Shows what the policies would delete, for all the repositories they cover or only the given ones.",
+		Run:   planRetentionCommand,
+	}
+	planRetentionCmd.Flags().BoolVar(&retentionShowKept, "show-kept", false, "Also show the tags that are kept")
+	applyRetentionCmd := &cobra.Command{
+		Use:   "apply [username/repo...]",
+		Short: "Delete the tags that the policies don't keep",
+		Long:  "Deletes what the policies decide, for all the repositories they cover or only the given ones.",
+		Run:   applyRetentionCommand,
+	}
+	applyRetentionCmd.Flags().BoolVarP(&retentionYes, "yes", "y", false, "Delete without asking for confirmation")
+	applyRetentionCmd.Flags().IntVar(&retentionConcurrency, "concurrency", 4, "How many tags to delete at the same time")
+	retentionCmd.AddCommand(planRetentionCmd)
+	retentionCmd.AddCommand(applyRetentionCmd)
+	rootCmd.AddCommand(retentionCmd)
+}
+
+func planRetentionCommand(cmd *cobra.Command, args []string) {
+	plans, err := planRetention(getAvailableDockerApi(), args)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	printRetentionPlans(plans, retentionShowKept)
+}
+
+func applyRetentionCommand(cmd *cobra.Command, args []string) {
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	plans, err := planRetention(dapi, args)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	total := printRetentionPlans(plans, false)
+	if total == 0 || (!retentionYes && !confirm("Delete them?")) {
+		return
+	}
+	var deleted, failed int
+	for _, plan := range plans {
+		tags := plan.deletedTags()
+		if len(tags) == 0 {
+			continue
+		}
+		errs := dapi.DeleteTags(plan.Repository.Namespace, plan.Repository.Name, tags, retentionConcurrency)
+		for _, tag := range tags {
+			if err, ok := errs[tag]; ok {
+				fmt.Printf("%s: %v\n", plan.Repository.Repository(), err)
+			}
+		}
+		deleted += len(tags) - len(errs)
+		failed += len(errs)
+	}
+	fmt.Printf("Deleted %d %s, %d failed\n", deleted, plural(deleted, "tag", "tags"), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+//planRetention evaluates the policy file for the repositories it covers, or only the given ones
+func planRetention(dapi *api.DockerApi, only []string) ([]*repositoryPlan, error) {
+	content, err := ioutil.ReadFile(retentionFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := api.ParseRetentionConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", retentionFile, err)
+	}
+	repositories, err := retentionRepositories(dapi, config, only)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var plans []*repositoryPlan
+	for _, ref := range repositories {
+		var policy *api.RetentionPolicy
+		for _, p := range config.Policies {
+			if p.Matches(ref.Repository()) {
+				policy = p
+				break
+			}
+		}
+		if policy == nil {
+			return nil, fmt.Errorf("no policy covers %s", ref.Repository())
+		}
+		tags, err := getAllTags(dapi, ref)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch tags for %s: %v", ref.Repository(), err)
+		}
+		decisions, err := policy.Plan(tags, now)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, &repositoryPlan{Repository: ref, Decisions: decisions})
+	}
+	return plans, nil
+}
+
+//retentionRepositories gets the repositories to apply the policies to.
+//Repository patterns like user/app-* are expanded by listing the repositories of the namespace.
+func retentionRepositories(dapi *api.DockerApi, config *api.RetentionConfig, only []string) ([]*api.ImageReference, error) {
+	var names []string
+	if len(only) > 0 {
+		names = only
+	} else {
+		seen := make(map[string]bool)
+		listed := make(map[string][]api.UserRepository)
+		for _, policy := range config.Policies {
+			for _, pattern := range policy.Repositories {
+				if !strings.ContainsAny(pattern, "*?[") {
+					names = append(names, pattern)
+					continue
+				}
+				namespace := strings.SplitN(pattern, "/", 2)[0]
+				if _, ok := listed[namespace]; !ok {
+					repos, err := dapi.GetRepositories(namespace)
+					if err != nil {
+						return nil, fmt.Errorf("could not list the repositories of %s: %v", namespace, err)
+					}
+					listed[namespace] = repos
+				}
+				for _, repo := range listed[namespace] {
+					name := repo.Namespace + "/" + repo.Name
+					if policy.Matches(name) && !seen[name] {
+						seen[name] = true
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+	var output []*api.ImageReference
+	for _, name := range names {
+		ref, err := api.ParseReference(name)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, ref)
+	}
+	return output, nil
+}
+
+//printRetentionPlans prints the tags that will be deleted, returning how many there are
+func printRetentionPlans(plans []*repositoryPlan, showKept bool) int {
+	var total int
+	for _, plan := range plans {
+		deleted := plan.deletedTags()
+		total += len(deleted)
+		kept := len(plan.Decisions) - len(deleted)
+		fmt.Printf("%s: %d to delete, %d to keep\n", plan.Repository.Repository(), len(deleted), kept)
+		decisions := plan.Decisions
+		sort.SliceStable(decisions, func(i, j int) bool {
+			return decisions[i].Tag.LastUpdated.After(decisions[j].Tag.LastUpdated)
+		})
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, d := range decisions {
+			if !d.Delete && !showKept {
+				continue
+			}
+			action := "keep"
+			if d.Delete {
+				action = "delete"
+			}
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", action, d.Tag.Name, timeElapsedRightNow(d.Tag.LastUpdated, false), d.Reason)
+		}
+		_ = w.Flush()
+	}
+	fmt.Printf("%d %s to delete in %d %s\n", total, plural(total, "tag", "tags"), len(plans), plural(len(plans), "repository", "repositories"))
+	return total
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var signaturesKey string
+var signaturesJson bool
+
+type signatureResult struct {
+	*api.Signature
+	Signer   string `json:"signer"`
+	Verified *bool  `json:"verified,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func init() {
+	signaturesCmd := &cobra.Command{
+		Use:     "signatures [username/repo:tag]",
+		Short:   "List and verify the signatures and attestations of an image",
+		Long:    "Finds cosign signatures and attestations, and the artifacts that refer to the image through the referrers api. Use --key to verify them offline with a public key.",
+		Aliases: []string{"sigs"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: signaturesCommand,
+	}
+	signaturesCmd.Flags().StringVar(&signaturesKey, "key", "", "A PEM encoded public key to verify the signatures with, exits with an error if none of them is valid")
+	signaturesCmd.Flags().BoolVar(&signaturesJson, "json", false, "Output the signatures as json")
+	rootCmd.AddCommand(signaturesCmd)
+}
+
+func signaturesCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	var key crypto.PublicKey
+	if signaturesKey != "" {
+		content, err := ioutil.ReadFile(signaturesKey)
+		if err == nil {
+			key, err = api.ParsePublicKey(content)
+		}
+		if err != nil {
+			fmt.Printf("Could not read key %s: %v\n", signaturesKey, err)
+			os.Exit(1)
+		}
+	}
+	registry := getRegistryClient(ref)
+	desc, err := registry.HeadManifest(ref.Repository(), ref.Reference())
+	if err != nil {
+		fmt.Printf("Could not fetch %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	signatures, err := registry.GetSignatures(ref.Repository(), desc.Digest)
+	if err != nil {
+		fmt.Printf("Could not fetch signatures for %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	var results []signatureResult
+	verified := 0
+	for _, s := range signatures {
+		result := signatureResult{Signature: s, Signer: s.Signer()}
+		if key != nil && (s.Kind == "signature" || s.Kind == "attestation") {
+			err := s.Verify(key, desc.Digest)
+			ok := err == nil
+			result.Verified = &ok
+			if ok {
+				verified++
+			} else {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	if signaturesJson {
+		output, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		printSignatures(ref, desc.Digest, results)
+	}
+	if key != nil && verified == 0 {
+		if !signaturesJson {
+			fmt.Println("No valid signatures found")
+		}
+		os.Exit(1)
+	}
+}
+
+func printSignatures(ref *api.ImageReference, digest string, results []signatureResult) {
+	fmt.Printf("%s@%s\n", ref, digest)
+	if len(results) == 0 {
+		fmt.Println("No signatures found")
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tSOURCE\tSIGNER\tDETAILS\tVERIFIED")
+	for _, r := range results {
+		details := r.PredicateType
+		if details == "" {
+			var annotations []string
+			for k, v := range r.Annotations {
+				annotations = append(annotations, fmt.Sprintf("%s=%s", k, v))
+			}
+			sort.Strings(annotations)
+			details = strings.Join(annotations, " ")
+		}
+		verified := "-"
+		if r.Verified != nil && *r.Verified {
+			verified = "yes"
+		} else if r.Verified != nil {
+			verified = "no: " + r.Error
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Kind, r.Source, r.Signer, details, verified)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var tagDiffPlatform string
+var tagDiffJson bool
+
+func init() {
+	diffTagCmd := &cobra.Command{
+		Use:   "diff [username/repo:tag] [username/repo:tag]",
+		Short: "Show what changed between two images",
+		Long:  "Compares the layers, sizes and configs of two images. Multi platform images are compared for a single platform.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("two images are required")
+			}
+			return nil
+		},
+		Run: diffTagCommand,
+	}
+	diffTagCmd.Flags().StringVar(&tagDiffPlatform, "platform", "linux/amd64", "The platform to compare for multi platform images")
+	diffTagCmd.Flags().BoolVar(&tagDiffJson, "json", false, "Output the differences as json")
+	tagCmd.AddCommand(diffTagCmd)
+}
+
+func diffTagCommand(cmd *cobra.Command, args []string) {
+	platform, err := api.ParsePlatform(tagDiffPlatform)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	var images []*api.Image
+	for _, arg := range args {
+		ref, err := api.ParseReference(arg)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		image, err := getRegistryClient(ref).GetImage(ref.Repository(), ref.Reference(), platform)
+		if err != nil {
+			fmt.Printf("Could not fetch %s: %v\n", ref, err)
+			os.Exit(1)
+		}
+		images = append(images, image)
+	}
+	diff := api.DiffImages(images[0], images[1])
+	if tagDiffJson {
+		output, _ := json.MarshalIndent(diff, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	fmt.Printf("--- %s %s\n", args[0], diff.FromDigest)
+	fmt.Printf("+++ %s %s\n", args[1], diff.ToDigest)
+	if diff.IsEmpty() {
+		fmt.Println("The images are identical")
+		return
+	}
+	if diff.SameContent() {
+		fmt.Println("Same layers and config, different manifests")
+		return
+	}
+	fmt.Printf("Layers: %d shared, %d added, %d removed\n", len(diff.SharedLayers), len(diff.AddedLayers), len(diff.RemovedLayers))
+	if diff.LayersReordered {
+		fmt.Println("The shared layers are in a different order")
+	}
+	for _, l := range diff.RemovedLayers {
+		fmt.Printf("  - %s %s\n", l.Digest, formatSize(l.Size))
+	}
+	for _, l := range diff.AddedLayers {
+		fmt.Printf("  + %s %s\n", l.Digest, formatSize(l.Size))
+	}
+	sign := "+"
+	if diff.SizeDelta < 0 {
+		sign = "-"
+	}
+	fmt.Printf("Size: %s -> %s (%s%s)\n", formatSize(diff.FromSize), formatSize(diff.ToSize), sign, formatSize(abs(diff.SizeDelta)))
+	if len(diff.Config) > 0 {
+		fmt.Println("Config:")
+	}
+	for _, c := range diff.Config {
+		switch {
+		case c.From == "":
+			fmt.Printf("  + %s: %s\n", c.Field, c.To)
+		case c.To == "":
+			fmt.Printf("  - %s: %s\n", c.Field, c.From)
+		default:
+			fmt.Printf("  ~ %s: %s -> %s\n", c.Field, c.From, c.To)
+		}
+	}
+}
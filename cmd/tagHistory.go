@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"text/tabwriter"
+)
+
+func init() {
+	historyTagCmd := &cobra.Command{
+		Use:   "history [username/repo:tag]",
+		Short: "Show which digests a tag pointed to over time",
+		Long: "Shows every digest that a tag was seen pointing to, and when. Tags are recorded every time they are fetched\n" +
+			"by any command, so the history only covers the times the cli looked at the repository. `watch` keeps it up to date.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: historyTagCommand,
+	}
+	mutationsTagCmd := &cobra.Command{
+		Use:   "mutations [username/repo]",
+		Short: "List the tags that were seen pointing to more than one digest",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one repository accepted")
+			} else if len(args) < 1 {
+				return errors.New("repository is missing")
+			}
+			return nil
+		},
+		Run: mutationsTagCommand,
+	}
+	addFilterFlag(mutationsTagCmd, "name, digest, previous, digests, changed", api.TagMutation{})
+	tagCmd.AddCommand(historyTagCmd)
+	tagCmd.AddCommand(mutationsTagCmd)
+}
+
+//getTagHistory records the current tags of a repository and then reads its history
+func getTagHistory(ref *api.ImageReference) *api.RepositoryHistory {
+	_, err := getAllTags(getAvailableDockerApi(), ref)
+	if err != nil {
+		log.Warningf("Could not fetch tags for %s, showing the recorded history: %v", ref.Repository(), err)
+	}
+	history, err := getHistoryStore().Get(ref.Repository())
+	if err != nil {
+		fmt.Printf("Could not read the history of %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	return history
+}
+
+func historyTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	entries := getTagHistory(ref).Tag(ref.Tag)
+	if len(entries) == 0 {
+		fmt.Printf("%s was never seen\n", ref)
+		os.Exit(1)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DIGEST\tFIRST SEEN\tLAST SEEN")
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", e.Digest, e.FirstSeen.Local().Format("2006-01-02 15:04"), e.LastSeen.Local().Format("2006-01-02 15:04"))
+	}
+	_ = w.Flush()
+}
+
+func mutationsTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	history := getTagHistory(ref)
+	mutations := history.Mutations()
+	var tags []string
+	for _, tag := range history.MutatedTags() {
+		if matchesListFilter(api.TagMutation(mutations[tag])) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		fmt.Printf("No tags of %s were seen changing\n", ref.Repository())
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TAG\tDIGESTS\tLAST CHANGED\tNOW\tBEFORE")
+	for _, tag := range tags {
+		entries := mutations[tag]
+		current, previous := entries[len(entries)-1], entries[len(entries)-2]
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", tag, len(entries), timeElapsedRightNow(current.FirstSeen, false),
+			shortDigest(current.Digest), shortDigest(previous.Digest))
+	}
+	_ = w.Flush()
+}
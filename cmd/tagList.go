@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var tagListSort string
+var tagListMatch []string
+var tagListRegex bool
+var tagListSince string
+var tagListBefore string
+var tagListPlatform string
+var tagListGroup bool
+
+const tagFilterFields = "name, digest, size, updated, updater, arch, os, platform, images"
+
+func init() {
+	lsTagCmd := &cobra.Command{
+		Use:   "ls [username/repo]",
+		Short: "List the tags of a repository",
+		Long:  "Lists all the tags of a repository with their digest, size, platforms and when they were last updated.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one repository accepted")
+			} else if len(args) < 1 {
+				return errors.New("repository is missing")
+			}
+			return nil
+		},
+		Run: lsTagCommand,
+	}
+	lsTagCmd.Flags().StringVar(&tagListSort, "sort", "date", "Sort by name, semver, date or size. Dates, versions and sizes are sorted newest or biggest first")
+	lsTagCmd.Flags().StringArrayVar(&tagListMatch, "match", nil, "Only show tags whose name matches this glob, like 1.*-alpine. Can be repeated")
+	lsTagCmd.Flags().BoolVar(&tagListRegex, "regex", false, "Treat --match patterns as regular expressions")
+	lsTagCmd.Flags().StringVar(&tagListSince, "since", "", "Only show tags updated after this date, like 2020-01-31, or within this age, like 30d")
+	lsTagCmd.Flags().StringVar(&tagListBefore, "before", "", "Only show tags updated before this date, like 2020-01-31, or older than this age, like 30d")
+	lsTagCmd.Flags().StringVar(&tagListPlatform, "platform", "", "Only show tags that have an image for this platform, like linux/arm64/v8")
+	lsTagCmd.Flags().BoolVar(&tagListGroup, "group", false, "Show tags that point to the same image as a single row, with the most specific tag first")
+	aliasesTagCmd := &cobra.Command{
+		Use:   "aliases [username/repo:tag]",
+		Short: "Show the tags that point to the same image as a tag",
+		Long:  "Finds the tags that point to the same image, like latest, 1, 1.25 and 1.25.3, and shows the most specific one first.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: aliasesTagCommand,
+	}
+	addFilterFlag(lsTagCmd, tagFilterFields, api.Tag{})
+	tagCmd.AddCommand(lsTagCmd)
+	tagCmd.AddCommand(aliasesTagCmd)
+}
+
+func lsTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	var since, before time.Time
+	if tagListSince != "" {
+		since, err = parseTime(tagListSince)
+	}
+	if err == nil && tagListBefore != "" {
+		before, err = parseTime(tagListBefore)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	dapi := getAvailableDockerApi()
+	tags, err := getAllTags(dapi, ref)
+	if err != nil {
+		fmt.Printf("Could not fetch tags for %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	if len(tagListMatch) > 0 {
+		tags, err = tags.FilterNames(tagListMatch, tagListRegex)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+	tags = filterTagList(tags.UpdatedBetween(since, before))
+	if tagListPlatform != "" {
+		platform, err := api.ParsePlatform(tagListPlatform)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		tags = tags.FilterPlatform(platform)
+	}
+	err = tags.Sort(tagListSort)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if tagListGroup {
+		printTagGroups(tags)
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TAG\tDIGEST\tSIZE\tPLATFORMS\tUPDATED\tBY")
+	for _, tag := range tags {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", tag.Name, formatTagColumns(&tag))
+	}
+	_ = w.Flush()
+}
+
+//printTagGroups prints a row for each image with the most specific tag that points to it and its aliases
+func printTagGroups(tags api.TagList) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TAG\tALIASES\tDIGEST\tSIZE\tPLATFORMS\tUPDATED\tBY")
+	for _, group := range tags.GroupByDigest() {
+		aliases := strings.Join(group.Aliases(), ",")
+		if aliases == "" {
+			aliases = "-"
+		}
+		tag := group.Canonical()
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", tag.Name, aliases, formatTagColumns(tag))
+	}
+	_ = w.Flush()
+}
+
+//formatTagColumns formats the digest, size, platforms, update time and updater of a tag as tab separated columns
+func formatTagColumns(tag *api.Tag) string {
+	var platforms []string
+	for _, p := range tag.Platforms() {
+		platforms = append(platforms, p.String())
+	}
+	updater := tag.LastUpdaterUsername
+	if updater == "" {
+		updater = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s", shortDigest(tag.Digest), formatSize(int64(tag.FullSize)),
+		strings.Join(platforms, ","), timeElapsedRightNow(tag.LastUpdated, false), updater)
+}
+
+func aliasesTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	tags, err := getAllTags(getAvailableDockerApi(), ref)
+	if err != nil {
+		fmt.Printf("Could not fetch tags for %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	group := tags.Aliases(ref.Tag)
+	if group == nil {
+		fmt.Printf("%s has no tag %s\n", ref.Repository(), ref.Tag)
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s\n", group.Canonical().Name, group.Digest)
+	for _, alias := range group.Aliases() {
+		fmt.Printf("  %s\n", alias)
+	}
+}
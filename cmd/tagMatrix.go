@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var tagMatrixPlatform string
+var tagMatrixRequire []string
+
+func init() {
+	matrixTagCmd := &cobra.Command{
+		Use:   "matrix [username/repo]",
+		Short: "Show the platforms each tag is available for",
+		Long:  "Shows a grid of tags and platforms, with the size and digest of each image. Use --require to report tags that are missing platforms.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one repository accepted")
+			} else if len(args) < 1 {
+				return errors.New("repository is missing")
+			}
+			return nil
+		},
+		Run: matrixTagCommand,
+	}
+	matrixTagCmd.Flags().StringVar(&tagMatrixPlatform, "platform", "", "Only show tags for this platform, like linux/arm64/v8")
+	matrixTagCmd.Flags().StringSliceVar(&tagMatrixRequire, "require", nil, "Report tags that are missing any of these platforms")
+	addFilterFlag(matrixTagCmd, tagFilterFields, api.Tag{})
+	tagCmd.AddCommand(matrixTagCmd)
+}
+
+func matrixTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	dapi := getAvailableDockerApi()
+	tags, err := getAllTags(dapi, ref)
+	if err != nil {
+		fmt.Printf("Could not fetch tags for %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	tags = filterTagList(tags)
+	var platforms []*api.Platform
+	if tagMatrixPlatform != "" {
+		platform, err := api.ParsePlatform(tagMatrixPlatform)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		tags = tags.FilterPlatform(platform)
+		platforms = append(platforms, platform)
+	} else {
+		platforms = getTagPlatforms(tags)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	header := []string{"TAG"}
+	for _, p := range platforms {
+		header = append(header, p.String())
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, tag := range tags {
+		row := []string{tag.Name}
+		for _, p := range platforms {
+			img := tag.GetImage(p)
+			if img == nil {
+				row = append(row, "-")
+			} else {
+				row = append(row, fmt.Sprintf("%s %s", formatSize(int64(img.Size)), shortDigest(img.Digest)))
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+	if len(tagMatrixRequire) > 0 {
+		if !reportMissingPlatforms(tags, tagMatrixRequire) {
+			os.Exit(1)
+		}
+	}
+}
+
+//getTagPlatforms gets all the distinct platforms of the given tags, sorted by name.
+//Spellings of the same platform, like `linux/arm64` and `linux/arm64/v8`, share a column named after the one with the variant.
+func getTagPlatforms(tags api.TagList) []*api.Platform {
+	var platforms []*api.Platform
+	seen := make(map[string]int)
+	for _, tag := range tags {
+		for _, p := range tag.Platforms() {
+			key := p.Normalized().String()
+			ix, ok := seen[key]
+			if !ok {
+				seen[key] = len(platforms)
+				platforms = append(platforms, p)
+			} else if platforms[ix].Variant == "" {
+				//A platform without a variant would also match the other variants of its architecture
+				platforms[ix] = p
+			}
+		}
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		return platforms[i].String() < platforms[j].String()
+	})
+	return platforms
+}
+
+//reportMissingPlatforms prints the tags that have no image for each of the required platforms.
+//Returns true if all the tags have all the platforms.
+func reportMissingPlatforms(tags api.TagList, required []string) bool {
+	var platforms []*api.Platform
+	for _, r := range required {
+		platform, err := api.ParsePlatform(r)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		platforms = append(platforms, platform)
+	}
+	missing := tags.MissingPlatforms(platforms)
+	if len(missing) == 0 {
+		fmt.Printf("\nAll %d tags have %s\n", len(tags), strings.Join(required, ", "))
+		return true
+	}
+	for _, p := range platforms {
+		var names []string
+		for _, tag := range tags {
+			for _, mp := range missing[tag.Name] {
+				if mp == p {
+					names = append(names, tag.Name)
+				}
+			}
+		}
+		if len(names) > 0 {
+			fmt.Printf("\n%d tag%s no %s: %s\n", len(names), plural(len(names), " has", "s have"), p, strings.Join(names, ", "))
+		}
+	}
+	return false
+}
+
+//shortDigest shortens a sha256 digest to its first 12 characters
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"time"
+)
+
+var tagRmRegex bool
+var tagRmOlderThan string
+var tagRmDryRun bool
+var tagRmYes bool
+var tagRmThreshold int
+var tagRmConcurrency int
+
+func init() {
+	rmTagCmd := &cobra.Command{
+		Use:   "rm [username/repo] [pattern...]",
+		Short: "Delete tags matching globs or regular expressions",
+		Long: "Deletes all the tags of a repository that match any of the patterns. The matching tags are always listed first.\n" +
+			"If more tags than the threshold match then nothing is deleted unless --yes is given, otherwise you're asked to confirm.\n" +
+			"The manifests that the tags pointed to are kept, see `manifest untagged`.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("repository is missing")
+			} else if len(args) < 2 {
+				return errors.New("at least one tag pattern is required")
+			}
+			return nil
+		},
+		Run: rmTagCommand,
+	}
+	rmTagCmd.Flags().BoolVar(&tagRmRegex, "regex", false, "Treat the patterns as regular expressions instead of globs")
+	rmTagCmd.Flags().StringVar(&tagRmOlderThan, "older-than", "", "Only delete tags that were last updated before this age, like 90d, or date, like 2020-01-31")
+	rmTagCmd.Flags().BoolVar(&tagRmDryRun, "dry-run", false, "Only list the tags that would be deleted")
+	rmTagCmd.Flags().BoolVarP(&tagRmYes, "yes", "y", false, "Delete without asking for confirmation")
+	rmTagCmd.Flags().IntVar(&tagRmThreshold, "threshold", 10, "When more tags than this match, only list them unless --yes is given")
+	rmTagCmd.Flags().IntVar(&tagRmConcurrency, "concurrency", 4, "How many tags to delete at the same time")
+	addFilterFlag(rmTagCmd, tagFilterFields, api.Tag{})
+	tagCmd.AddCommand(rmTagCmd)
+}
+
+func rmTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	var before time.Time
+	if tagRmOlderThan != "" {
+		before, err = parseTime(tagRmOlderThan)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+	dapi := getAvailableDockerApi()
+	if !dapi.IsAuthenticated() {
+		fmt.Printf("You need to login first.\n")
+		os.Exit(1)
+	}
+	tags, err := getAllTags(dapi, ref)
+	if err != nil {
+		fmt.Printf("Could not fetch tags for %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	tags, err = tags.FilterNames(args[1:], tagRmRegex)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	tags = filterTagList(tags.UpdatedBetween(time.Time{}, before))
+	if len(tags) == 0 {
+		fmt.Printf("No tags of %s match\n", ref.Repository())
+		return
+	}
+	_ = tags.Sort("name")
+	fmt.Printf("%d %s of %s will be deleted:\n", len(tags), plural(len(tags), "tag", "tags"), ref.Repository())
+	var names []string
+	for _, tag := range tags {
+		fmt.Printf("  %s\t%s\n", tag.Name, timeElapsedRightNow(tag.LastUpdated, false))
+		names = append(names, tag.Name)
+	}
+	if tagRmDryRun {
+		return
+	}
+	if !tagRmYes {
+		if len(tags) > tagRmThreshold {
+			fmt.Printf("More than %d tags match, run again with --yes to delete them\n", tagRmThreshold)
+			return
+		}
+		if !confirm("Delete them?") {
+			return
+		}
+	}
+	failed := dapi.DeleteTags(ref.Namespace, ref.Name, names, tagRmConcurrency)
+	var failedNames []string
+	for name := range failed {
+		failedNames = append(failedNames, name)
+	}
+	sort.Strings(failedNames)
+	for _, name := range failedNames {
+		fmt.Printf("%v\n", failed[name])
+	}
+	deleted := len(names) - len(failed)
+	fmt.Printf("Deleted %d %s, %d failed\n", deleted, plural(deleted, "tag", "tags"), len(failed))
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var tagResolveSameVariant bool
+
+func init() {
+	resolveTagCmd := &cobra.Command{
+		Use:   "resolve [username/repo:constraint]",
+		Short: "Find the newest tag that satisfies a version constraint",
+		Long: "Resolves a version constraint to the tag with the highest version and prints it with its digest.\n" +
+			"Constraints can be partial versions like 3.12, ~3.12 for patch updates, ^3.12 for minor updates, ranges like >=3.11 <3.13 or 3.11 - 3.12,\n" +
+			"and alternatives separated with ||. A variant like -slim can follow the version, for example `tag resolve python:~3.12-slim`.\n" +
+			"Constraints without a variant only match plain versions, so ~3.12 never resolves to 3.12.7-slim.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: resolveTagCommand,
+	}
+	resolveTagCmd.Flags().BoolVar(&tagResolveSameVariant, "same-variant", false, "Only match tags with exactly the same variant, so -slim doesn't match -slim-bookworm")
+	tagCmd.AddCommand(resolveTagCmd)
+}
+
+func resolveTagCommand(cmd *cobra.Command, args []string) {
+	//Constraints aren't valid tags, so they are split off before parsing the repository
+	repo, constraintStr := args[0], "*"
+	if i := strings.LastIndex(args[0], ":"); i > strings.LastIndex(args[0], "/") {
+		repo, constraintStr = args[0][:i], args[0][i+1:]
+	}
+	ref, err := api.ParseReference(repo)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	constraint, err := api.ParseConstraint(constraintStr)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	constraint.SameVariant = tagResolveSameVariant
+	dapi := getAvailableDockerApi()
+	tags, err := getAllTags(dapi, ref)
+	if err != nil {
+		fmt.Printf("Could not fetch tags for %s: %v\n", ref.Repository(), err)
+		os.Exit(1)
+	}
+	tag := tags.Resolve(constraint)
+	if tag == nil {
+		fmt.Printf("No tag of %s satisfies %s\n", ref.Repository(), constraintStr)
+		os.Exit(1)
+	}
+	fmt.Printf("%s\t%s\n", tag.Name, tag.Digest)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+//waitTimeoutExitCode is the exit code when waiting times out, the same that `timeout` uses
+const waitTimeoutExitCode = 124
+
+var tagWaitDigest string
+var tagWaitChangedSince string
+var tagWaitTimeout time.Duration
+var tagWaitInterval time.Duration
+
+func init() {
+	waitTagCmd := &cobra.Command{
+		Use:   "wait [username/repo:tag]",
+		Short: "Wait until a tag is published or changes",
+		Long: "Polls a tag until it exists, points to the given digest, or points to something other than the given digest.\n" +
+			"Polling slows down the longer the tag doesn't change. Exits with 0 when the tag is ready and 124 on timeout.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("only one image accepted")
+			} else if len(args) < 1 {
+				return errors.New("image is missing")
+			}
+			return nil
+		},
+		Run: waitTagCommand,
+	}
+	waitTagCmd.Flags().StringVar(&tagWaitDigest, "digest", "", "Wait until the tag points to this digest")
+	waitTagCmd.Flags().StringVar(&tagWaitChangedSince, "changed-since", "", "Wait until the tag points to a digest other than this one")
+	waitTagCmd.Flags().DurationVar(&tagWaitTimeout, "timeout", 20*time.Minute, "How long to wait")
+	waitTagCmd.Flags().DurationVar(&tagWaitInterval, "interval", 15*time.Second, "How often to check at first")
+	tagCmd.AddCommand(waitTagCmd)
+}
+
+func waitTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if ref.Digest != "" {
+		fmt.Printf("Only tags can be waited for, use --digest to wait for a digest\n")
+		os.Exit(1)
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	for _, digest := range []string{tagWaitDigest, tagWaitChangedSince} {
+		if digest != "" && !api.IsDigest(digest) {
+			fmt.Printf("Invalid digest %s\n", digest)
+			os.Exit(1)
+		}
+	}
+	if tagWaitInterval <= 0 {
+		fmt.Printf("The interval has to be positive\n")
+		os.Exit(1)
+	}
+	condition := api.TagCondition{Digest: tagWaitDigest, ChangedFrom: tagWaitChangedSince}
+	desc, err := getRegistryClient(ref).WaitForTag(ref.Repository(), ref.Tag, condition, tagWaitTimeout, tagWaitInterval)
+	if err == api.ErrWaitTimeout {
+		fmt.Printf("Timed out after %s waiting for %s\n", tagWaitTimeout, ref)
+		os.Exit(waitTimeoutExitCode)
+	}
+	if err != nil {
+		fmt.Printf("Could not check %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s@%s\n", ref, desc.Digest)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "View and manage the tags of a repository",
+}
+
+func init() {
+	addTagCmd := &cobra.Command{
+		Use:   "add [username/repo:tag or username/repo@digest] [newtag...]",
+		Short: "Add tags to an existing image",
+		Long:  "Points new tags to an image that is already in the repository. The manifest is pushed again as is, so the digest doesn't change.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("image is missing")
+			} else if len(args) < 2 {
+				return errors.New("at least one new tag is required")
+			}
+			return nil
+		},
+		Run: addTagCommand,
+	}
+	tagCmd.AddCommand(addTagCmd)
+	rootCmd.AddCommand(tagCmd)
+}
+
+func addTagCommand(cmd *cobra.Command, args []string) {
+	ref, err := api.ParseReference(args[0])
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	registry := getPushRegistryClient(ref)
+	manifest, err := registry.TagManifest(ref.Repository(), ref.Reference(), args[1:]...)
+	if err != nil {
+		fmt.Printf("Could not tag %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	for _, tag := range args[1:] {
+		fmt.Printf("Tagged %s as %s\n", ref.WithDigest(manifest.Digest), ref.WithTag(tag))
+	}
+}
+
+//getAllTags gets all the tags of a repository, and records where they point in the tag history
+func getAllTags(dapi *api.DockerApi, ref *api.ImageReference) (api.TagList, error) {
+	tags, err := dapi.GetAllTags(ref.Namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	err = getHistoryStore().Record(ref.Repository(), tags, time.Now())
+	if err != nil {
+		log.Warningf("Could not record the tag history of %s: %v", ref.Repository(), err)
+	}
+	return tags, nil
+}
+
+func getHistoryStore() *api.HistoryStore {
+	return &api.HistoryStore{Dir: getDataPath("history")}
+}
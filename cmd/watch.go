@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sp0x/docker-hub-cli/api"
+	"github.com/sp0x/docker-hub-cli/requests"
+	"github.com/spf13/cobra"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"time"
+)
+
+var watchInterval time.Duration
+var watchExec string
+var watchPost string
+var watchQuiet bool
+var watchOnce bool
+var watchStateFile string
+
+const (
+	watchSinkExec = "exec"
+	watchSinkPost = "post"
+)
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch [username/repo...]",
+		Short: "Watch repositories for new, deleted or changed tags",
+		Long: "Polls the tags of repositories and reports an event when a tag is created, deleted or points to a new digest.\n" +
+			"Events are printed as json lines, and can also run a command or be posted to an url.\n" +
+			"Commands get the event in the DHC_EVENT, DHC_REPOSITORY, DHC_TAG, DHC_DIGEST and DHC_PREVIOUS_DIGEST environment variables.\n" +
+			"The tags that were seen are saved, so restarting doesn't repeat events. The first time a repository is watched its tags are only saved.\n" +
+			"Events that the command fails for or that can't be posted are sent again to only that command or url on the next check.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("at least one repository is required")
+			}
+			return nil
+		},
+		Run: watchCommand,
+	}
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Minute, "How often to check the tags")
+	watchCmd.Flags().StringVar(&watchExec, "exec", "", "Run this shell command for each event")
+	watchCmd.Flags().StringVar(&watchPost, "post", "", "Post each event as json to this url")
+	watchCmd.Flags().BoolVarP(&watchQuiet, "quiet", "q", false, "Don't print the events")
+	watchCmd.Flags().BoolVar(&watchOnce, "once", false, "Check the tags once and exit, for running from cron")
+	watchCmd.Flags().StringVar(&watchStateFile, "state", getDataPath("watch.json"), "The file that the seen tags are saved in")
+	rootCmd.AddCommand(watchCmd)
+}
+
+func watchCommand(cmd *cobra.Command, args []string) {
+	var refs []*api.ImageReference
+	for _, arg := range args {
+		ref, err := api.ParseReference(arg)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		refs = append(refs, ref)
+	}
+	if watchPost != "" {
+		postURL, err := url.Parse(watchPost)
+		if err != nil || (postURL.Scheme != "http" && postURL.Scheme != "https") || postURL.Host == "" {
+			fmt.Printf("Invalid --post url %s, an http or https url is required\n", watchPost)
+			os.Exit(1)
+		}
+	}
+	state, err := api.LoadWatchState(watchStateFile)
+	if err != nil {
+		fmt.Printf("Could not read %s: %v\n", watchStateFile, err)
+		os.Exit(1)
+	}
+	dapi := getAvailableDockerApi()
+	for {
+		for _, ref := range refs {
+			pollRepository(dapi, ref, state)
+		}
+		if watchOnce {
+			return
+		}
+		time.Sleep(watchInterval)
+	}
+}
+
+//pollRepository checks the tags of a repository and reports the events since the last check
+func pollRepository(dapi *api.DockerApi, ref *api.ImageReference, state *api.WatchState) {
+	repository := ref.Repository()
+	tags, err := getAllTags(dapi, ref)
+	if err != nil {
+		log.Errorf("Could not fetch tags for %s: %v", repository, err)
+		return
+	}
+	snapshot := api.SnapshotTags(tags)
+	var pending []api.PendingEvent
+	for _, event := range state.Pending[repository] {
+		if failed := deliverEvent(event.TagEvent, event.Sinks); len(failed) > 0 {
+			pending = append(pending, api.PendingEvent{TagEvent: event.TagEvent, Sinks: failed})
+		}
+	}
+	previous, seen := state.Repositories[repository]
+	if seen {
+		for _, event := range api.DiffSnapshots(repository, previous, snapshot, time.Now()) {
+			printEvent(event)
+			if failed := deliverEvent(event, watchSinks()); len(failed) > 0 {
+				//Only the sinks that failed get the event again on the next check
+				pending = append(pending, api.PendingEvent{TagEvent: event, Sinks: failed})
+			}
+		}
+	} else {
+		log.Infof("Watching %d tags of %s", len(snapshot), repository)
+	}
+	state.Repositories[repository] = snapshot
+	state.SetPending(repository, pending)
+	err = state.Save(watchStateFile)
+	if err != nil {
+		log.Errorf("Could not save %s: %v", watchStateFile, err)
+	}
+}
+
+//watchSinks gets the sinks that events are delivered to besides stdout
+func watchSinks() []string {
+	var sinks []string
+	if watchExec != "" {
+		sinks = append(sinks, watchSinkExec)
+	}
+	if watchPost != "" {
+		sinks = append(sinks, watchSinkPost)
+	}
+	return sinks
+}
+
+func printEvent(event api.TagEvent) {
+	if watchQuiet {
+		return
+	}
+	content, _ := json.Marshal(event)
+	fmt.Println(string(content))
+}
+
+//deliverEvent sends an event to the given sinks, returning the ones that failed.
+//Sinks that are no longer configured are skipped.
+func deliverEvent(event api.TagEvent, sinks []string) []string {
+	var failed []string
+	for _, sink := range sinks {
+		switch {
+		case sink == watchSinkExec && watchExec != "":
+			command := exec.Command("sh", "-c", watchExec)
+			command.Stdout = os.Stdout
+			command.Stderr = os.Stderr
+			command.Env = append(os.Environ(),
+				"DHC_EVENT="+event.Type,
+				"DHC_REPOSITORY="+event.Repository,
+				"DHC_TAG="+event.Tag,
+				"DHC_DIGEST="+event.Digest,
+				"DHC_PREVIOUS_DIGEST="+event.PreviousDigest,
+			)
+			if err := command.Run(); err != nil {
+				log.Errorf("Command failed for %s:%s: %v", event.Repository, event.Tag, err)
+				failed = append(failed, sink)
+			}
+		case sink == watchSinkPost && watchPost != "":
+			client := &http.Client{Timeout: 10 * time.Second}
+			if _, err := requests.Post(client, watchPost, event, ""); err != nil {
+				log.Errorf("Could not post %s:%s to %s: %v", event.Repository, event.Tag, watchPost, err)
+				failed = append(failed, sink)
+			}
+		}
+	}
+	return failed
+}
@@ -30,7 +30,10 @@ func Post(client *http.Client, route string, objData interface{}, token string)
 		return nil, err
 	}
 	buff := bytes.NewBuffer(data)
-	req, _ := http.NewRequest("POST", route, buff)
+	req, err := http.NewRequest("POST", route, buff)
+	if err != nil {
+		return nil, err
+	}
 	setupHeaders(req)
 	if token != "" {
 		authenticateRequest(req, token)
@@ -56,7 +59,10 @@ func Put(client *http.Client, route string, objData interface{}, token string) (
 		return nil, err
 	}
 	buff := bytes.NewBuffer(data)
-	req, _ := http.NewRequest("PUT", route, buff)
+	req, err := http.NewRequest("PUT", route, buff)
+	if err != nil {
+		return nil, err
+	}
 	setupHeaders(req)
 	if token != "" {
 		authenticateRequest(req, token)
@@ -82,7 +88,10 @@ func Patch(client *http.Client, route string, objData interface{}, token string)
 		return nil, err
 	}
 	buff := bytes.NewBuffer(data)
-	req, _ := http.NewRequest("PATCH", route, buff)
+	req, err := http.NewRequest("PATCH", route, buff)
+	if err != nil {
+		return nil, err
+	}
 	setupHeaders(req)
 	if token != "" {
 		authenticateRequest(req, token)
@@ -103,7 +112,10 @@ func Get(client *http.Client, route string, token string) ([]byte, error) {
 	if client == nil {
 		return []byte{}, errors.New("null transport client")
 	}
-	req, _ := http.NewRequest("GET", route, nil)
+	req, err := http.NewRequest("GET", route, nil)
+	if err != nil {
+		return nil, err
+	}
 	setupHeaders(req)
 	if token != "" {
 		authenticateRequest(req, token)
@@ -124,7 +136,10 @@ func GetWithHeaders(client *http.Client, url string, headers map[string]string)
 	if client == nil {
 		return []byte{}, errors.New("null transport client")
 	}
-	req, _ := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 	//setupHeaders(req)
 	for k, v := range headers {
 		req.Header.Set(k, v)
@@ -145,7 +160,10 @@ func Delete(client *http.Client, route string, token string) ([]byte, error) {
 	if client == nil {
 		return []byte{}, errors.New("null transport client")
 	}
-	req, _ := http.NewRequest("DELETE", route, nil)
+	req, err := http.NewRequest("DELETE", route, nil)
+	if err != nil {
+		return nil, err
+	}
 	setupHeaders(req)
 	if token != "" {
 		authenticateRequest(req, token)